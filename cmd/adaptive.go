@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/broganross/color-run/internal/frame"
+	"github.com/rs/zerolog/log"
+)
+
+// How often the stream's frame rate is checked against the target
+const resolutionInterval = 5 * time.Second
+
+// Colors the makers are timed with, so calibrating doesn't use up any palettes
+var calibrationColors = []color.RGBA{
+	{230, 57, 70, 255},
+	{241, 250, 238, 255},
+	{168, 218, 220, 255},
+	{69, 123, 157, 255},
+	{29, 53, 87, 255},
+}
+
+// Returns the size to render frames at so the maker keeps up with the target frame
+// rate, timing a second of frames at each size tried.  Only the maker is timed, not
+// ffmpeg.
+func calibrateResolution(ctx context.Context, o makerOptions, ar *frame.AdaptiveResolution) image.Point {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	return frame.CalibrateResolution(ctx, ar, time.Second, func(size image.Point) frame.Maker {
+		colors := make(chan *color.RGBA)
+		go func() {
+			for i := 0; ; i++ {
+				select {
+				case colors <- &calibrationColors[i%len(calibrationColors)]:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		scaled := scaleMakerOptions(o, size)
+		// the stream's grain isn't thrown off by the frames timed here
+		scaled.Rand = rand.New(rand.NewSource(1))
+		return newFrameMaker(colors, scaled)
+	})
+}
+
+// Returns the options for rendering at size rather than o's width and height, with the
+// region of interest and scroll speed scaled to match
+func scaleMakerOptions(o makerOptions, size image.Point) makerOptions {
+	scale := func(v int, full int, to int) int {
+		return v * to / full
+	}
+	if o.ROI != (image.Rectangle{}) {
+		o.ROI = image.Rect(
+			scale(o.ROI.Min.X, o.Width, size.X), scale(o.ROI.Min.Y, o.Height, size.Y),
+			scale(o.ROI.Max.X, o.Width, size.X), scale(o.ROI.Max.Y, o.Height, size.Y),
+		)
+	}
+	o.Speed = o.Speed * float64(size.X) / float64(o.Width)
+	o.Width, o.Height = size.X, size.Y
+	return o
+}
+
+// Steps the size the maker renders at down while the stream's frame rate, from fps, is
+// behind the target, checking every interval until ctx is done.  It has to be behind on
+// two checks in a row, so a stall while ffmpeg restarts isn't taken for slow rendering,
+// and the check after starting or stepping down is skipped while the rate settles.
+func watchResolution(ctx context.Context, am *frame.AdaptiveMaker, fps func() float64, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := math.Inf(1)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current := fps()
+		size, changed := am.Update(max(current, last))
+		last = current
+		if changed {
+			log.Info().Int("width", size.X).Int("height", size.Y).Float64("fps", current).Msg("stepped render resolution down")
+			last = math.Inf(1)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/broganross/color-run/internal/frame"
+)
+
+func TestScaleMakerOptions(t *testing.T) {
+	o := makerOptions{Width: 1920, Height: 1080, ROI: image.Rect(480, 270, 1440, 810), Speed: 4}
+	got := scaleMakerOptions(o, image.Pt(960, 540))
+	if got.Width != 960 || got.Height != 540 {
+		t.Errorf("scaled to %dx%d, want 960x540", got.Width, got.Height)
+	}
+	if want := image.Rect(240, 135, 720, 405); got.ROI != want {
+		t.Errorf("region of interest scaled to %v, want %v", got.ROI, want)
+	}
+	if got.Speed != 2 {
+		t.Errorf("speed scaled to %g, want 2", got.Speed)
+	}
+	// no region stays no region
+	if got := scaleMakerOptions(makerOptions{Width: 1920, Height: 1080}, image.Pt(960, 540)); got.ROI != (image.Rectangle{}) {
+		t.Errorf("empty region of interest scaled to %v", got.ROI)
+	}
+}
+
+func TestWatchResolution(t *testing.T) {
+	full := image.Pt(1920, 1080)
+	made := make(chan image.Point, 10)
+	am := &frame.AdaptiveMaker{
+		Resolution: &frame.AdaptiveResolution{Full: full, Min: image.Pt(64, 36), TargetFPS: 30},
+		New: func(size image.Point) frame.Maker {
+			made <- size
+			return newFrameMaker(make(chan *color.RGBA), makerOptions{Width: size.X, Height: size.Y})
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	readings := make(chan float64)
+	done := make(chan struct{})
+	go func() {
+		watchResolution(ctx, am, func() float64 { return <-readings }, time.Millisecond)
+		close(done)
+	}()
+	// a reading is only taken once the one before it has been acted on
+	for _, fps := range []float64{10, 10, 10, 40, 10, 30} {
+		readings <- fps
+	}
+	cancel()
+	<-done
+	close(made)
+	var sizes []image.Point
+	for size := range made {
+		sizes = append(sizes, size)
+	}
+	// the first check and the one after a step are skipped, the rest step down when the
+	// check before was behind too
+	if len(sizes) != 2 || sizes[0] != full || sizes[1].X >= full.X {
+		t.Errorf("made makers at %v, want %v then one smaller", sizes, full)
+	}
+}
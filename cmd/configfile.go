@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/broganross/color-run/internal/config"
+)
+
+// Replaces conf with the config file at conf.ConfigFile, then parses args with fs again so
+// the flags, which are bound to conf's fields, override the file and the environment.
+func loadConfigFile(conf *config.Config, fs *flag.FlagSet, args []string) error {
+	c, err := config.LoadFile(conf.ConfigFile)
+	if err != nil {
+		return err
+	}
+	c.ConfigFile = conf.ConfigFile
+	*conf = *c
+	return fs.Parse(args)
+}
+
+// Reads the size and frame rate from the profile at conf.Profile, then parses args with
+// fs again so flags given explicitly override the profile.
+func loadProfile(conf *config.Config, fs *flag.FlagSet, args []string) error {
+	if err := conf.LoadProfile(conf.Profile); err != nil {
+		return err
+	}
+	return fs.Parse(args)
+}
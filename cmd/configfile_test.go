@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/broganross/color-run/internal/config"
+)
+
+func TestLoadConfigFilePrecedence(t *testing.T) {
+	t.Setenv("COLORRUN_IMAGEHEIGHT", "360")
+	t.Setenv("COLORRUN_FRAMERATE", "60")
+	conf := config.Config{ConfigFile: "../internal/config/testdata/config.yaml"}
+	fs := flag.NewFlagSet("color-run", flag.ContinueOnError)
+	fs.IntVar(&conf.FrameRate, "fps", 0, "")
+	fs.StringVar(&conf.Effect, "e", "", "")
+	args := []string{"-fps", "24"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfigFile(&conf, fs, args); err != nil {
+		t.Fatal(err)
+	}
+	// file < environment < flags, with the defaults under all of them
+	if conf.ImageWidth != 1280 || conf.Effect != "fade" {
+		t.Errorf("got width %d and effect %q, want the file's 1280 and fade", conf.ImageWidth, conf.Effect)
+	}
+	if conf.ImageHeight != 360 {
+		t.Errorf("got height %d, want the environment's 360", conf.ImageHeight)
+	}
+	if conf.FrameRate != 24 {
+		t.Errorf("got %d fps, want the flag's 24", conf.FrameRate)
+	}
+	if conf.Sink != "ffmpeg" {
+		t.Errorf("got sink %q, want the default", conf.Sink)
+	}
+}
+
+func TestLoadProfilePrecedence(t *testing.T) {
+	// 1280x720 at 60 fps
+	conf := config.Config{Profile: "../internal/config/testdata/basic.ini", ImageWidth: 640, ImageHeight: 480, FrameRate: 24}
+	fs := flag.NewFlagSet("color-run", flag.ContinueOnError)
+	fs.IntVar(&conf.ImageWidth, "w", conf.ImageWidth, "")
+	fs.IntVar(&conf.FrameRate, "fps", conf.FrameRate, "")
+	args := []string{"-w", "1920"}
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadProfile(&conf, fs, args); err != nil {
+		t.Fatal(err)
+	}
+	// profile < flags
+	if conf.ImageWidth != 1920 {
+		t.Errorf("got width %d, want the flag's 1920", conf.ImageWidth)
+	}
+	if conf.ImageHeight != 720 || conf.FrameRate != 60 {
+		t.Errorf("got height %d at %d fps, want the profile's 720 at 60", conf.ImageHeight, conf.FrameRate)
+	}
+}
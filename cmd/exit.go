@@ -0,0 +1,34 @@
+package main
+
+import "errors"
+
+// Why the stream stopped
+type stopCause int
+
+const (
+	// Shutdown was requested with a signal
+	stopSignal stopCause = iota
+	// The output failed, ie. ffmpeg exited with an error
+	stopOutput
+	// The output ran out of frames because colormind stopped supplying colors
+	stopColorMind
+)
+
+// Classifies the error the sink goroutine sent when it returned
+func sinkStopCause(err error) stopCause {
+	if errors.Is(err, errSinkExit) && err != errSinkExit {
+		return stopOutput
+	}
+	return stopColorMind
+}
+
+// Exit code for the cause, so supervisors can restart on failures but not on a clean shutdown
+func exitCode(cause stopCause) int {
+	switch cause {
+	case stopOutput:
+		return 1
+	case stopColorMind:
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		cause stopCause
+		want  int
+	}{
+		{"signal", stopSignal, 0},
+		{"ffmpeg failed", sinkStopCause(fmt.Errorf("%w: %w", errSinkExit, errors.New("exit status 1"))), 1},
+		{"colors ran out", sinkStopCause(errSinkExit), 2},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.cause); got != tt.want {
+			t.Errorf("%s: got exit code %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -6,27 +6,35 @@ import (
 	"flag"
 	"fmt"
 	"image"
+	"image/color"
+	"io"
 	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime/pprof"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/broganross/color-run/internal/colormind"
 	"github.com/broganross/color-run/internal/config"
+	"github.com/broganross/color-run/internal/errorlog"
 	"github.com/broganross/color-run/internal/frame"
+	imgenc "github.com/broganross/color-run/internal/image"
+	"github.com/broganross/color-run/internal/logging"
+	"github.com/broganross/color-run/internal/output"
 	"github.com/broganross/color-run/internal/twitch"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
-	ffmpeg "github.com/u2takey/ffmpeg-go"
+	"golang.org/x/term"
 )
 
 var Version = "development"
 var ErrInputClosed = errors.New("input channel has been closed")
-var errFfmpegExit = errors.New("ffmpeg errorred")
+var errSinkExit = errors.New("output stopped")
 
 func memDump(filePath string) {
 	f, err := os.Create(filePath)
@@ -40,22 +48,111 @@ func memDump(filePath string) {
 
 func main() {
 	conf := config.Config{}
-	if err := envconfig.Process("colorrun", &conf); err != nil {
+	if err := envconfig.Process(config.EnvPrefix, &conf); err != nil {
 		log.Error().Err(err).Msg("parsing environment variables")
 		os.Exit(1)
 	}
 	flag.IntVar(&conf.ImageWidth, "w", conf.ImageWidth, "image width")
 	flag.IntVar(&conf.ImageHeight, "h", conf.ImageHeight, "image height")
+	flag.IntVar(&conf.FrameRate, "fps", conf.FrameRate, "frames per second")
+	flag.BoolVar(&conf.AdaptiveResolution, "adaptive-resolution", conf.AdaptiveResolution, "render at a lower resolution when frames can't be made at the target fps, calibrated at startup and stepped down while streaming")
+	flag.IntVar(&conf.MinWidth, "min-width", conf.MinWidth, "narrowest the adaptive resolution renders at")
+	flag.IntVar(&conf.MinHeight, "min-height", conf.MinHeight, "shortest the adaptive resolution renders at")
+	flag.Float64Var(&conf.TargetFPS, "target-fps", conf.TargetFPS, "frames per second the adaptive resolution renders fast enough for, 0 is -fps")
+	flag.StringVar(&conf.VideoCodec, "codec", conf.VideoCodec, "ffmpeg video codec, ie. libx264 or h264_nvenc")
+	flag.StringVar(&conf.VideoBitrate, "bitrate", conf.VideoBitrate, "ffmpeg video bitrate, ie. 6000k")
+	flag.StringVar(&conf.Preset, "preset", conf.Preset, "ffmpeg encoder preset, ie. veryfast or ultrafast for slower machines")
+	flag.StringVar(&conf.ConfigFile, "config", conf.ConfigFile, "YAML or JSON file to read the config from, overridden by environment variables and flags")
+	flag.StringVar(&conf.Profile, "profile", conf.Profile, "OBS profile (basic.ini) or SDP file to read the image size and frame rate from")
 	flag.IntVar(&conf.FrameCount, "f", conf.FrameCount, "number of frames to transition from one color to another")
+	flag.Float64Var(&conf.TransitionSeconds, "transition-seconds", conf.TransitionSeconds, "seconds to transition from one color to another, overrides -f")
+	flag.StringVar(&conf.Easing, "easing", conf.Easing, "pace of each transition, linear, in-quad, out-quad or in-out-cubic")
+	flag.StringVar(&conf.Effect, "e", conf.Effect, "animation effect, scroll, fade, pulse or stops")
+	flag.IntVar(&conf.Stops, "stops", conf.Stops, "number of colors the stops effect shows at once")
+	flag.StringVar(&conf.Pairing, "pairing", conf.Pairing, "how the fade effect pairs colors, overlap or discrete")
+	flag.StringVar(&conf.SortPalette, "sort-palette", conf.SortPalette, "order of colors within a palette, none, hue, lightness or luminance")
+	flag.Float64Var(&conf.MinColorDistance, "min-color-distance", conf.MinColorDistance, "skip colors closer than this to the one before, 0.02 is barely visible and 1 is black to white (0 disables it)")
+	flag.StringVar(&conf.HueRange, "hue-range", conf.HueRange, "only show colors with a hue from,to degrees, ie. 330,60 for warm colors")
+	flag.Float64Var(&conf.MinLuminance, "min-luminance", conf.MinLuminance, "lighten colors darker than this lightness, from 0 to 1")
+	flag.Float64Var(&conf.MaxLuminance, "max-luminance", conf.MaxLuminance, "darken colors lighter than this lightness, from 0 to 1")
+	flag.IntVar(&conf.DwellFrames, "dwell", conf.DwellFrames, "number of frames the fade effect holds each color before transitioning")
+	flag.IntVar(&conf.MaxDeltaPerFrame, "max-delta-per-frame", conf.MaxDeltaPerFrame, "largest change of a channel between frames of the fade effect, in 8 bit levels (0 disables it)")
+	flag.BoolVar(&conf.TemporalDither, "temporal-dither", conf.TemporalDither, "dither the gradient with a pattern that changes every frame")
+	flag.BoolVar(&conf.Dither, "dither", conf.Dither, "dither the scroll gradient with a pattern fixed to each pixel")
+	flag.IntVar(&conf.Alpha, "alpha", conf.Alpha, "alpha from 0 to 255 every color is given, to composite the stream over other video")
+	flag.BoolVar(&conf.Premultiply, "premultiply", conf.Premultiply, "encode colors premultiplied by their alpha")
+	flag.StringVar(&conf.ColorProfile, "color-profile", conf.ColorProfile, "color space to mix in and tag the stream with, srgb, linear or oklab-mix")
+	flag.BoolVar(&conf.Smooth, "smooth", conf.Smooth, "curve the scroll gradient through its stops instead of mixing each pair of colors linearly")
+	flag.Float64Var(&conf.HueRotate, "hue-rotate", conf.HueRotate, "degrees per second the hue of every color is turned by")
+	flag.Float64Var(&conf.Grain, "grain", conf.Grain, "largest amount of noise added to the scroll gradient's channels, in 8 bit levels")
+	flag.Float64Var(&conf.NoiseIntensity, "noise", conf.NoiseIntensity, "film grain over every frame of any effect, from 0 to 1")
+	flag.Int64Var(&conf.Seed, "seed", conf.Seed, "seed for random choices and noise, 0 picks one from the time")
+	flag.StringVar(&conf.ROI, "roi", conf.ROI, "only render the scroll gradient in this x,y,width,height region")
+	flag.StringVar(&conf.ROIFill, "roi-fill", conf.ROIFill, "#rrggbb color filling the frame outside the region")
+	flag.Float64Var(&conf.Angle, "angle", conf.Angle, "degrees clockwise the scroll gradient is turned by, 90 runs top to bottom")
+	flag.Float64Var(&conf.ScrollSpeed, "scroll-speed", conf.ScrollSpeed, "pixels the scroll gradient moves each frame, in place of -f and -transition-seconds (0 uses them)")
+	flag.Func("stop-colors", "comma separated stop=#rrggbb colors always shown at a scroll gradient stop, 0 left, 1 middle, 2 right", func(v string) error {
+		conf.StopColors = strings.Split(v, ",")
+		return nil
+	})
 	flag.BoolVar(&conf.RandomModel, "r", conf.RandomModel, "use a random color mind model")
+	flag.StringVar(&conf.Model, "model", conf.Model, "color mind model to use when not choosing one at random")
+	flag.BoolVar(&conf.CachedModels, "cached-models", conf.CachedModels, "use the bundled model list instead of asking color mind for it")
+	flag.Func("models", "comma separated list of models to randomly choose from", func(v string) error {
+		conf.Models = strings.Split(v, ",")
+		return nil
+	})
+	flag.IntVar(&conf.ModelRotate, "model-rotate", conf.ModelRotate, "pick a new random color mind model every N seconds (requires -r)")
+	flag.IntVar(&conf.ColorMindConcurrency, "colormind-concurrency", conf.ColorMindConcurrency, "maximum number of color mind requests in flight at once")
+	flag.IntVar(&conf.ColorMindRetries, "colormind-retries", conf.ColorMindRetries, "times a failed palette request is retried, backing off between them")
+	flag.IntVar(&conf.ColorMindTimeout, "colormind-timeout", conf.ColorMindTimeout, "seconds a single color mind request may take")
+	flag.IntVar(&conf.OfflineAfter, "offline-after", conf.OfflineAfter, "make palettes up offline after this many color mind failures in a row, until it recovers (0 never does)")
+	flag.IntVar(&conf.ResetSeedEvery, "reset-seed-every", conf.ResetSeedEvery, "fetch every Nth palette without seeding it from the last one (0 always seeds)")
+	flag.StringVar(&conf.FetchMode, "fetch-mode", conf.FetchMode, "when palettes are fetched, continuous or ondemand")
+	flag.StringVar(&conf.ColorMindURL, "colormind-url", conf.ColorMindURL, "base URL of the color mind API")
+	flag.StringVar(&conf.ColorMindAPIPath, "colormind-api-path", conf.ColorMindAPIPath, "path palettes are requested from")
+	flag.StringVar(&conf.ColorMindListPath, "colormind-list-path", conf.ColorMindListPath, "path models are listed at")
+	flag.StringVar(&conf.PreloadPalettes, "preload-palettes", conf.PreloadPalettes, "JSON file of palettes to queue before fetching any")
 	flag.StringVar(&conf.StreamKey, "k", conf.StreamKey, "twitch stream key")
+	flag.BoolVar(&conf.StrictStreamKey, "strict-stream-key", conf.StrictStreamKey, "exit rather than warn when the stream key doesn't look like a twitch one")
+	flag.StringVar(&conf.OutputURL, "output-url", conf.OutputURL, "RTMP URL to stream to instead of twitch, ie. a YouTube or nginx-rtmp one")
+	flag.IntVar(&conf.OutputRestarts, "output-restarts", conf.OutputRestarts, "times in a row ffmpeg is restarted after failing before giving up, 0 never restarts it")
+	flag.StringVar(&conf.IngestRegion, "ingest-region", conf.IngestRegion, "only stream to twitch ingest servers with this in their name, ie. EU")
+	flag.BoolVar(&conf.IngestLowestLatency, "ingest-lowest-latency", conf.IngestLowestLatency, "stream to the twitch ingest server quickest to connect to rather than the default")
 	flag.StringVar(&conf.DumpDir, "d", conf.DumpDir, "dump frames to this directory as well as streaming")
+	flag.StringVar(&conf.Sink, "sink", conf.Sink, "where frames go, ffmpeg, raw or flv")
+	flag.StringVar(&conf.SinkPath, "o", conf.SinkPath, "file the raw and flv sinks write frames to, - for stdout")
+	flag.IntVar(&conf.EncoderWorkers, "encoder-workers", conf.EncoderWorkers, "frames the flv sink encodes at once, 0 for one per CPU")
+	flag.IntVar(&conf.PrebufferFrames, "prebuffer", conf.PrebufferFrames, "number of frames to buffer before starting the output")
+	flag.IntVar(&conf.DriftInterval, "drift-interval", conf.DriftInterval, "log how far the frames have drifted from the wall clock every N seconds (0 disables it)")
+	flag.IntVar(&conf.ShutdownTimeout, "shutdown-timeout", conf.ShutdownTimeout, "seconds the output has to finish on shutdown before it is killed (0 waits forever)")
+	flag.StringVar(&conf.DumpRaw, "dump-raw", conf.DumpRaw, "also dump raw RGBA frames to this file")
+	flag.StringVar(&conf.DumpFrames, "dump-frames", conf.DumpFrames, "also write every frame to this directory as a numbered PNG")
+	flag.StringVar(&conf.PNGCompression, "png-compression", conf.PNGCompression, "compression of the dumped PNGs, default, none, speed or best")
 	flag.StringVar(&conf.LogLevel, "l", conf.LogLevel, "logging verbosity")
+	flag.IntVar(&conf.LogSample, "log-sample", conf.LogSample, "only emit 1 in N debug messages (0 disables sampling)")
+	flag.StringVar(&conf.LogFormat, "log-format", conf.LogFormat, "log format, auto, console or json")
+	flag.StringVar(&conf.HTTPAddr, "http", conf.HTTPAddr, "address to serve the status endpoints on")
+	flag.IntVar(&conf.ErrorLogSize, "error-log-size", conf.ErrorLogSize, "number of recent errors kept for the /errors endpoint")
+	flag.BoolVar(&conf.Metrics, "metrics", conf.Metrics, "serve prometheus metrics on /metrics")
 	cpuProfile := flag.String("cpu-profile", "", "cpu profiling output path")
 	memProfile := flag.String("mem-profile", "", "memory profiling output path")
 	flag.Parse()
-	if conf.StreamKey == "" {
-		log.Fatal().Msg("stream key not set")
+	if conf.ConfigFile != "" {
+		if err := loadConfigFile(&conf, flag.CommandLine, os.Args[1:]); err != nil {
+			log.Error().Err(err).Msg("loading config file")
+			os.Exit(1)
+		}
+	}
+	if conf.Profile != "" {
+		if err := loadProfile(&conf, flag.CommandLine, os.Args[1:]); err != nil {
+			log.Error().Err(err).Msg("loading profile")
+			os.Exit(1)
+		}
+	}
+	if err := conf.Validate(); err != nil {
+		log.Error().Err(err).Msg("validating config")
+		os.Exit(1)
 	}
 	l, err := zerolog.ParseLevel(conf.LogLevel)
 	if err != nil {
@@ -63,6 +160,88 @@ func main() {
 		os.Exit(1)
 	}
 	zerolog.SetGlobalLevel(l)
+	pairing, err := frame.ParsePairingMode(conf.Pairing)
+	if err != nil {
+		log.Error().Err(err).Msg("parsing pairing mode")
+		os.Exit(1)
+	}
+	sortMode, err := colormind.ParseSortMode(conf.SortPalette)
+	if err != nil {
+		log.Error().Err(err).Msg("parsing palette sort mode")
+		os.Exit(1)
+	}
+	var hueRange *[2]float64
+	if conf.HueRange != "" {
+		r, err := colormind.ParseHueRange(conf.HueRange)
+		if err != nil {
+			log.Error().Err(err).Msg("parsing hue range")
+			os.Exit(1)
+		}
+		hueRange = &r
+	}
+	fetchMode, err := colormind.ParseFetchMode(conf.FetchMode)
+	if err != nil {
+		log.Error().Err(err).Msg("parsing fetch mode")
+		os.Exit(1)
+	}
+	stopPalette, stopColors, err := frame.ParseStopColors(conf.StopColors)
+	if err != nil {
+		log.Error().Err(err).Msg("parsing stop colors")
+		os.Exit(1)
+	}
+	var roi image.Rectangle
+	var roiFill color.RGBA
+	if conf.ROI != "" {
+		roi, err = frame.ParseROI(conf.ROI)
+		if err != nil {
+			log.Error().Err(err).Msg("parsing region of interest")
+			os.Exit(1)
+		}
+		if !roi.In(image.Rect(0, 0, conf.ImageWidth, conf.ImageHeight)) {
+			log.Error().Str("roi", conf.ROI).Msg("region of interest must be inside the image")
+			os.Exit(1)
+		}
+		fill, err := colormind.ParseHexColor(conf.ROIFill)
+		if err != nil {
+			log.Error().Err(err).Msg("parsing region of interest fill")
+			os.Exit(1)
+		}
+		roiFill = color.RGBA(*fill)
+	}
+	colorProfile, err := output.ParseColorProfile(conf.ColorProfile)
+	if err != nil {
+		log.Error().Err(err).Msg("parsing color profile")
+		os.Exit(1)
+	}
+	easing, err := frame.ParseEasing(conf.Easing)
+	if err != nil {
+		log.Error().Err(err).Msg("parsing easing")
+		os.Exit(1)
+	}
+	effect, err := frame.ParseEffect(conf.Effect)
+	if err != nil {
+		log.Error().Err(err).Msg("parsing effect")
+		os.Exit(1)
+	}
+	if effect == frame.EffectStops && conf.Stops < 2 {
+		log.Error().Int("stops", conf.Stops).Msg("the stops effect needs at least 2 colors")
+		os.Exit(1)
+	}
+	if unused := unusedFlags(effect, &conf); len(unused) > 0 {
+		log.Error().Str("effect", string(effect)).Strs("flags", unused).Msg("the effect doesn't use these settings")
+		os.Exit(1)
+	}
+	if conf.Sink != "ffmpeg" && conf.Sink != "raw" && conf.Sink != "flv" {
+		log.Error().Str("sink", conf.Sink).Msg("unknown sink")
+		os.Exit(1)
+	}
+	logFormat, err := logging.Format(conf.LogFormat, term.IsTerminal(int(os.Stderr.Fd())))
+	if err != nil {
+		log.Error().Err(err).Msg("parsing log format")
+		os.Exit(1)
+	}
+	log.Logger = log.Output(logging.Writer(logFormat, os.Stderr))
+	log.Logger = logging.Sample(log.Logger, conf.LogSample)
 	if *cpuProfile != "" {
 		f, err := os.Create(*cpuProfile)
 		if err != nil {
@@ -75,77 +254,385 @@ func main() {
 		defer f.Close()
 	}
 	ctx := context.Background()
-	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	ctx, stop := context.WithCancel(ctx)
 	defer stop()
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sigs)
+	go handleSignals(sigs, stop, func() { os.Exit(exitForced) })
 
 	colorChanSize := 15
+	seed := conf.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	log.Info().Int64("seed", seed).Msg("seeding random numbers")
+	rng := rand.New(rand.NewSource(seed))
+	frameRate := conf.FrameRate
+	transitionFrames := conf.FrameCount
+	if conf.TransitionSeconds > 0 {
+		transitionFrames = frame.TransitionFrames(conf.TransitionSeconds, frameRate)
+	}
+	transitionFrames = frame.ClampTransition(&log.Logger, transitionFrames, conf.ImageWidth, frameRate)
+	if transitionFrames < 1 {
+		log.Error().Int("frames", transitionFrames).Msg("transition must last at least one frame")
+		os.Exit(1)
+	}
 	// color palette channel
 	errorChannel := make(chan error, 5)
 	httpClient := &http.Client{}
+	errorLog := errorlog.New(conf.ErrorLogSize)
+	mux := http.NewServeMux()
+	mux.Handle("/errors", errorLog)
+	paletteStatus := &colormind.Status{}
+	mux.Handle("/palette", paletteStatus)
+	queueStats := &colormind.QueueStats{}
 
 	// creates the color mind client and retrieves a random color palette
 	cm := colormind.New()
 	cm.Client = httpClient
-	colorModel := "default"
+	cm.URL = strings.TrimSuffix(conf.ColorMindURL, "/")
+	cm.APIPath = conf.ColorMindAPIPath
+	cm.ListPath = conf.ColorMindListPath
+	cm.MaxConcurrent = conf.ColorMindConcurrency
+	cm.Timeout = time.Duration(conf.ColorMindTimeout) * time.Second
+	cm.Retry = colormind.DefaultRetry
+	cm.Retry.MaxAttempts = conf.ColorMindRetries + 1
+	if conf.CachedModels {
+		cm.CachedModels = colormind.BundledModels
+	}
+	colorModel := colormind.NewModel(conf.Model)
+	modelControl := &colormind.ModelControl{Model: colorModel, Client: cm, Allowed: conf.Models}
+	mux.HandleFunc("/model", modelControl.ServeModel)
+	mux.HandleFunc("/models", modelControl.ServeModels)
 	if conf.RandomModel {
 		models, err := cm.ListModelsWithContext(ctx)
 		if err != nil {
 			log.Error().Err(err).Msg("getting color mind models")
 			os.Exit(1)
 		}
-		colorModel = models[rand.Intn(len(models))]
+		models, err = colormind.FilterModels(models, conf.Models)
+		if err != nil {
+			log.Error().Err(err).Msg("filtering color mind models")
+			os.Exit(1)
+		}
+		colorModel.Set(colormind.PickModel(rng, models, ""))
+		if conf.ModelRotate > 0 {
+			// its own source, the rest of main carries on using rng
+			rotateRng := rand.New(rand.NewSource(rng.Int63()))
+			go colormind.RotateModel(ctx, colorModel, models, time.Duration(conf.ModelRotate)*time.Second, rotateRng)
+		}
+	}
+	// a pinned model is trusted as is in cached mode, it may be one of the daily models
+	if !conf.RandomModel && !conf.CachedModels && conf.Model != "default" {
+		models, err := cm.ListModelsWithContext(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("getting color mind models")
+			os.Exit(1)
+		}
+		if _, err := colormind.FilterModels(models, []string{conf.Model}); err != nil {
+			log.Error().Err(err).Msg("checking color mind model")
+			os.Exit(1)
+		}
 	}
-	colorChannel, colErrChan := colormind.PaletteQueue(ctx, colorModel, cm, colorChanSize)
-
-	ingestURL, err := twitch.IngestURL(ctx, httpClient, conf.StreamKey)
-	if err != nil {
-		log.Error().Err(err).Msg("getting ingest URL")
-		os.Exit(1)
+	if !conf.RandomModel && conf.ModelRotate > 0 {
+		log.Warn().Msg("model rotation requires a random model, ignoring it")
 	}
-
-	frameMaker := frame.LinearGradient{
-		ColorChannel: colorChannel,
-		Transition:   conf.FrameCount,
-		Rect:         image.Rect(0, 0, conf.ImageWidth, conf.ImageHeight),
+	if !conf.RandomModel && len(conf.Models) > 0 {
+		log.Warn().Msg("restricting models requires a random model, ignoring it")
 	}
-	go frameMaker.Run()
-	outPath := ingestURL
-	if conf.DumpDir != "" {
-		outPath = filepath.Join(conf.DumpDir, "out.flv")
+	queueOpts := colormind.QueueOptions{
+		Size:           colorChanSize,
+		OnPalette:      paletteStatus.Set,
+		ResetSeedEvery: conf.ResetSeedEvery,
+		FetchMode:      fetchMode,
+		Stats:          queueStats,
 	}
+	if conf.PreloadPalettes != "" {
+		queueOpts.Preload, err = colormind.LoadPalettes(conf.PreloadPalettes)
+		if err != nil {
+			log.Error().Err(err).Msg("preloading palettes")
+			os.Exit(1)
+		}
+	}
+	if sort := colormind.SortBy(sortMode); sort != nil {
+		queueOpts.Filters = append(queueOpts.Filters, sort)
+	}
+	if hueRange != nil {
+		queueOpts.Filters = append(queueOpts.Filters, colormind.HueRange(*hueRange, colormind.DefaultHueRejects))
+	}
+	if conf.MinLuminance > 0 || conf.MaxLuminance < 1 {
+		queueOpts.Filters = append(queueOpts.Filters, colormind.ClampLightness(conf.MinLuminance, conf.MaxLuminance))
+	}
+	queueOpts.Filters = append(queueOpts.Filters, colormind.DropAdjacentDuplicates)
+	if conf.MinColorDistance > 0 {
+		queueOpts.Filters = append(queueOpts.Filters, colormind.MinDistance(conf.MinColorDistance))
+	}
+	if conf.Alpha < 255 {
+		queueOpts.Filters = append(queueOpts.Filters, colormind.SetAlpha(uint8(conf.Alpha)))
+		stopPalette = colormind.SetAlpha(uint8(conf.Alpha))(stopPalette)
+	}
+	var paletteClient colormind.PaletteClient = cm
+	if conf.OfflineAfter > 0 {
+		paletteClient = &colormind.FallbackClient{
+			Primary:  cm,
+			Fallback: colormind.NewOfflineClient(rng.Int63()),
+			After:    conf.OfflineAfter,
+		}
+	}
+	colorChannel, colErrChan := colormind.PaletteQueue(ctx, colorModel, paletteClient, queueOpts)
 
-	proc := ffmpeg.
-		Input("pipe:0", ffmpeg.KwArgs{
-			"f":          "rawvideo",
-			"pix_fmt":    "rgba",
-			"video_size": fmt.Sprintf("%dx%d", conf.ImageWidth, conf.ImageHeight),
-		}).
-		WithInput(&frameMaker).
-		Output(outPath, ffmpeg.KwArgs{
-			"framerate": 30,
-			"c:v":       "libx264",
-			"b:v":       "6000k",
-			"preset":    "veryfast",
-			"f":         "flv",
-		}).
-		OverWriteOutput().
-		ErrorToStdOut().
-		Compile()
-
-	if err != nil {
-		log.Error().Err(err).Msg("getting stderr pipe")
-		os.Exit(10)
+	makerOpts := makerOptions{
+		Effect:         effect,
+		Width:          conf.ImageWidth,
+		Height:         conf.ImageHeight,
+		Transition:     transitionFrames,
+		TemporalDither: conf.TemporalDither,
+		Dither:         conf.Dither,
+		Premultiply:    conf.Premultiply,
+		MixSpace:       colorProfile.Mix,
+		HueRotate:      conf.HueRotate / float64(frameRate),
+		Easing:         easing,
+		Pairing:        pairing,
+		DwellFrames:    conf.DwellFrames,
+		MaxDelta:       conf.MaxDeltaPerFrame,
+		Grain:          conf.Grain,
+		Rand:           rand.New(rand.NewSource(rng.Int63())),
+		Smooth:         conf.Smooth,
+		Stops:          conf.Stops,
+		ROI:            roi,
+		Fill:           roiFill,
+		Angle:          conf.Angle,
+		Speed:          conf.ScrollSpeed,
+		Palette:        stopPalette,
+		StopColors:     stopColors,
+		NoiseIntensity: conf.NoiseIntensity,
+	}
+	if conf.NoiseIntensity > 0 {
+		// drawn only when used, so seeded runs without grain are as they were
+		makerOpts.NoiseSeed = rng.Int63()
+	}
+	var frameMaker frame.Maker
+	var adaptive *frame.AdaptiveMaker
+	if conf.AdaptiveResolution {
+		targetFPS := conf.TargetFPS
+		if targetFPS == 0 {
+			targetFPS = float64(frameRate)
+		}
+		resolution := &frame.AdaptiveResolution{
+			Full:      image.Pt(conf.ImageWidth, conf.ImageHeight),
+			Min:       image.Pt(conf.MinWidth, conf.MinHeight),
+			TargetFPS: targetFPS,
+		}
+		size := calibrateResolution(ctx, makerOpts, resolution)
+		log.Info().Int("width", size.X).Int("height", size.Y).Float64("target-fps", targetFPS).Msg("calibrated render resolution")
+		// makers swapped in mid-stream get grain of their own, as the first's is in use
+		resizeSeed := rng.Int63()
+		made := 0
+		adaptive = &frame.AdaptiveMaker{
+			Resolution: resolution,
+			New: func(size image.Point) frame.Maker {
+				o := scaleMakerOptions(makerOpts, size)
+				if made > 0 {
+					o.Rand = rand.New(rand.NewSource(resizeSeed + int64(made)))
+				}
+				made++
+				return newFrameMaker(colorChannel, o)
+			},
+		}
+		frameMaker = adaptive
+	} else {
+		frameMaker = newFrameMaker(colorChannel, makerOpts)
+	}
+	frameSize := frameMaker.Bounds().Size()
+	frameCounter := &frame.Counter{R: frameMaker, FrameSize: frameSize.X * frameSize.Y * 4}
+	mux.Handle("/stats", &statsHandler{frames: frameCounter, queue: queueStats})
+	var metrics *metricsHandler
+	if conf.Metrics {
+		metrics = &metricsHandler{
+			frames:     frameCounter,
+			queue:      queueStats,
+			queueDepth: func() int { return len(colorChannel) },
+		}
+		mux.Handle("/metrics", metrics)
+	}
+	var input io.Reader = frameCounter
+	if conf.HTTPAddr != "" {
+		// copying every frame is only worth it when someone can ask for one
+		snapshot := frame.NewSnapshot(frameSize.X, frameSize.Y)
+		mux.Handle("/snapshot", snapshot)
+		input = io.TeeReader(input, snapshot)
+	}
+	if adaptive != nil {
+		go watchResolution(ctx, adaptive, frameCounter.FPS, resolutionInterval)
+	}
+	makerDone := make(chan struct{})
+	go func() {
+		frame.RunContext(ctx, frameMaker)
+		close(makerDone)
+	}()
+	var httpServer *http.Server
+	if conf.HTTPAddr != "" {
+		httpServer = &http.Server{
+			Addr:              conf.HTTPAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			log.Info().Str("address", conf.HTTPAddr).Msg("serving http")
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error().Err(err).Msg("serving http")
+			}
+		}()
+	}
+	var rawDump *frame.RawDumpWriter
+	var rawDumpFile *os.File
+	if conf.DumpRaw != "" {
+		rawDumpFile, err = os.Create(conf.DumpRaw)
+		if err != nil {
+			log.Error().Err(err).Msg("creating raw dump file")
+			os.Exit(1)
+		}
+		rawDump, err = frame.NewRawDumpWriter(rawDumpFile, frameSize.X, frameSize.Y, frameRate)
+		if err != nil {
+			log.Error().Err(err).Msg("creating raw dump")
+			os.Exit(1)
+		}
+		input = io.TeeReader(input, rawDump)
+	}
+	var pngDump *frame.PNGDumpWriter
+	if conf.DumpFrames != "" {
+		level, err := imgenc.ParsePNGCompression(conf.PNGCompression)
+		if err != nil {
+			log.Error().Err(err).Msg("parsing png compression")
+			os.Exit(1)
+		}
+		// a second of frames can wait to be encoded before they're dropped
+		pngDump, err = frame.NewPNGDumpWriter(conf.DumpFrames, frameSize.X, frameSize.Y, frameRate, level)
+		if err != nil {
+			log.Error().Err(err).Msg("creating png dump")
+			os.Exit(1)
+		}
+		input = io.TeeReader(input, pngDump)
+	}
+	shutdownTimeout := time.Duration(conf.ShutdownTimeout) * time.Second
+	var sink output.Sink
+	var sinkFile *os.File
+	// checked once up front rather than on every restart
+	if conf.Sink == "ffmpeg" && conf.DumpDir == "" && conf.OutputURL == "" {
+		if err := twitch.ValidateStreamKey(conf.StreamKey); err != nil {
+			if conf.StrictStreamKey {
+				log.Error().Err(err).Msg("checking stream key")
+				os.Exit(1)
+			}
+			log.Warn().Err(err).Msg("checking stream key, streaming anyway")
+		}
+	}
+	switch conf.Sink {
+	case "raw", "flv":
+		sinkFile = os.Stdout
+		if conf.SinkPath != "-" && conf.SinkPath != "" {
+			sinkFile, err = os.Create(conf.SinkPath)
+			if err != nil {
+				log.Error().Str("sink", conf.Sink).Err(err).Msg("creating output")
+				os.Exit(1)
+			}
+		}
+		sink = &output.Raw{W: sinkFile}
+		if conf.Sink == "flv" {
+			sink = &output.FLV{
+				W:              sinkFile,
+				Encoder:        &output.ScreenVideo{Width: frameSize.X, Height: frameSize.Y},
+				Width:          frameSize.X,
+				Height:         frameSize.Y,
+				FrameRate:      frameRate,
+				EncoderWorkers: conf.EncoderWorkers,
+			}
+		}
+	default:
+		// looked up again for every restart, as the ingest server may be what failed
+		newFFmpeg := func(ctx context.Context) (output.Sink, error) {
+			var outPaths []string
+			switch {
+			case conf.DumpDir != "":
+				outPaths = []string{filepath.Join(conf.DumpDir, "out.flv")}
+			case conf.OutputURL != "":
+				outPaths = []string{conf.OutputURL}
+			default:
+				var ingestOpts []twitch.IngestOption
+				if conf.IngestRegion != "" {
+					ingestOpts = append(ingestOpts, twitch.PreferRegion(conf.IngestRegion))
+				}
+				if conf.IngestLowestLatency {
+					ingestOpts = append(ingestOpts, twitch.LowestLatency())
+				}
+				var err error
+				outPaths, err = twitch.IngestURLs(ctx, httpClient, conf.StreamKey, ingestOpts...)
+				if err != nil {
+					return nil, fmt.Errorf("getting ingest URL: %w", err)
+				}
+			}
+			// when ffmpeg can't connect to one ingest server the next is tried
+			failover := &output.Failover{FrameSize: frameSize.X * frameSize.Y * 4}
+			for _, outPath := range outPaths {
+				failover.Sinks = append(failover.Sinks, &output.FFmpeg{
+					URL:             outPath,
+					Width:           frameSize.X,
+					Height:          frameSize.Y,
+					ScaleWidth:      conf.ImageWidth,
+					ScaleHeight:     conf.ImageHeight,
+					PixelFormat:     frameMaker.PixelFormat(),
+					FrameRate:       frameRate,
+					VideoCodec:      conf.VideoCodec,
+					VideoBitrate:    conf.VideoBitrate,
+					Preset:          conf.Preset,
+					ShutdownTimeout: shutdownTimeout,
+					ColorArgs:       colorProfile.FFmpegArgs,
+					ColorMatrix:     colorProfile.Matrix,
+				})
+			}
+			return failover, nil
+		}
+		restarts := conf.OutputRestarts
+		if conf.DumpDir != "" {
+			// ffmpeg would overwrite the dump
+			restarts = 0
+		}
+		supervisor := &output.Supervisor{
+			New:         newFFmpeg,
+			FrameSize:   frameSize.X * frameSize.Y * 4,
+			MaxRestarts: restarts,
+		}
+		if metrics != nil {
+			supervisor.OnRestart = metrics.restarted
+		}
+		sink = supervisor
+	}
+	if conf.PrebufferFrames > 0 {
+		sink = &output.Prebuffer{
+			Sink:      sink,
+			Frames:    conf.PrebufferFrames,
+			FrameSize: frameSize.X * frameSize.Y * 4,
+		}
+	}
+	if conf.DriftInterval > 0 {
+		sink = &output.DriftMonitor{
+			Sink:      sink,
+			FrameRate: frameRate,
+			FrameSize: frameSize.X * frameSize.Y * 4,
+			Interval:  time.Duration(conf.DriftInterval) * time.Second,
+		}
 	}
 	go func() {
-		log.Info().Msg("waiting for ffmpeg")
-		if err := proc.Run(); err != nil {
-			errorChannel <- fmt.Errorf("%w: %w", errFfmpegExit, err)
+		if err := sink.Run(ctx, input); err != nil {
+			errorChannel <- fmt.Errorf("%w: %w", errSinkExit, err)
+			return
 		}
-		// ffmpeg has inconsitent exit codes, TODO: figure out a way to handle this so that we stop when ffmpeg fails
-		log.Info().Int("exit-code", proc.ProcessState.ExitCode()).Msg("ffmpeg exited")
-		errorChannel <- errFfmpegExit
+		errorChannel <- errSinkExit
 	}()
 
+	sinkExited := false
+	cause := stopSignal
 	for {
 		done := false
 		select {
@@ -162,9 +649,12 @@ func main() {
 
 		case err := <-errorChannel:
 			log.Error().Err(err).Send()
-			if errors.Is(err, errFfmpegExit) {
+			errorLog.Add("output", err)
+			if errors.Is(err, errSinkExit) {
 				stop()
 				done = true
+				sinkExited = true
+				cause = sinkStopCause(err)
 				if *cpuProfile != "" {
 					pprof.StopCPUProfile()
 				}
@@ -172,13 +662,68 @@ func main() {
 					memDump(*memProfile)
 				}
 			}
-		case err := <-colErrChan:
+		case err, ok := <-colErrChan:
+			if !ok {
+				colErrChan = nil
+				continue
+			}
 			log.Error().Err(err).Send()
+			errorLog.Add("colormind", err)
 		}
 		if done {
 			break
 		}
 	}
+	frameMaker.Close()
+	// let the sink drain the buffered frames so ffmpeg can finalize the stream.  ffmpeg
+	// is killed after the timeout, the extra second lets that be reported.
+	if !sinkExited {
+		var expired <-chan time.Time
+		if shutdownTimeout > 0 {
+			expired = time.After(shutdownTimeout + time.Second)
+		}
+		select {
+		case err := <-errorChannel:
+			if err != errSinkExit {
+				log.Error().Err(err).Send()
+			}
+		case <-expired:
+			log.Warn().Msg("timed out waiting for the output to finish")
+		}
+	}
+	// the context is done so the palette queue closes its channels once any request
+	// in flight returns
+	if !waitClosed(shutdownTimeout, makerDone, drained(colorChannel), drained(colErrChan)) {
+		log.Warn().Msg("timed out waiting for the frame maker and palette queue to stop")
+	}
+	if httpServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			log.Error().Err(err).Msg("shutting down http server")
+		}
+		cancel()
+	}
+	if sinkFile != nil && sinkFile != os.Stdout {
+		if err := sinkFile.Close(); err != nil {
+			log.Error().Err(err).Msg("closing raw output")
+		}
+	}
+	if pngDump != nil {
+		if err := pngDump.Close(); err != nil {
+			log.Error().Err(err).Msg("closing png dump")
+		}
+		if n := pngDump.Dropped(); n > 0 {
+			log.Warn().Int("frames", n).Msg("png dump fell behind and dropped frames")
+		}
+	}
+	if rawDump != nil {
+		if err := rawDump.Close(); err != nil {
+			log.Error().Err(err).Msg("closing raw dump")
+		}
+		if err := rawDumpFile.Close(); err != nil {
+			log.Error().Err(err).Msg("closing raw dump file")
+		}
+	}
 
-	os.Exit(0)
+	os.Exit(exitCode(cause))
 }
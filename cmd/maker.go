@@ -0,0 +1,165 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+
+	"github.com/broganross/color-run/internal/config"
+	"github.com/broganross/color-run/internal/frame"
+)
+
+// Settings for whichever frame maker the effect selects, each maker ignores the ones
+// it doesn't use.  Pulse only uses Transition and MixSpace of the shared settings and
+// stops those and Smooth, main rejects the others for them, see unusedOptions.
+type makerOptions struct {
+	Effect         frame.Effect
+	Width          int
+	Height         int
+	Transition     int
+	TemporalDither bool
+	Dither         bool
+	Premultiply    bool
+	MixSpace       frame.MixSpace
+	// degrees per frame
+	HueRotate float64
+	// fade and scroll only
+	Easing frame.Easing
+	// fade only
+	Pairing     frame.PairingMode
+	DwellFrames int
+	MaxDelta    int
+	// stops only
+	Stops int
+	// scroll and stops
+	Smooth bool
+	// scroll only
+	Grain      float64
+	Rand       *rand.Rand
+	ROI        image.Rectangle
+	Fill       color.RGBA
+	Angle      float64
+	Speed      float64
+	Palette    []*color.RGBA
+	StopColors map[int]int
+	// any effect, film grain from 0 to 1 seeded with NoiseSeed
+	NoiseIntensity float64
+	NoiseSeed      int64
+}
+
+// Creates the frame maker for the effect, reset so it can be read from as soon as it's
+// run.  They all read from the same color channel and produce frames of the same
+// format, so the rest of the pipeline doesn't care which one it gets.  Effects without
+// a maker get the scroll.
+func newFrameMaker(colors chan *color.RGBA, o makerOptions) frame.Maker {
+	newMaker, ok := frameMakers[o.Effect]
+	if !ok {
+		newMaker = newScroll
+	}
+	m := newMaker(colors, o)
+	if o.NoiseIntensity > 0 {
+		m = &frame.Noise{Maker: m, Intensity: o.NoiseIntensity, Seed: o.NoiseSeed}
+	}
+	m.Reset()
+	return m
+}
+
+// Constructors for each effect's frame maker.  Adding an effect means adding it to
+// frame.Effects and a constructor here.
+var frameMakers = map[frame.Effect]func(colors chan *color.RGBA, o makerOptions) frame.Maker{
+	frame.EffectScroll: newScroll,
+	frame.EffectFade:   newFade,
+	frame.EffectPulse:  newPulse,
+	frame.EffectStops:  newStops,
+}
+
+func newScroll(colors chan *color.RGBA, o makerOptions) frame.Maker {
+	return &frame.LinearGradient{
+		ColorChannel:   colors,
+		Transition:     o.Transition,
+		Rect:           image.Rect(0, 0, o.Width, o.Height),
+		TemporalDither: o.TemporalDither,
+		Dither:         o.Dither,
+		Premultiply:    o.Premultiply,
+		MixSpace:       o.MixSpace,
+		HueRotate:      o.HueRotate,
+		Easing:         o.Easing,
+		Grain:          o.Grain,
+		Rand:           o.Rand,
+		Smooth:         o.Smooth,
+		ROI:            o.ROI,
+		Fill:           o.Fill,
+		Angle:          o.Angle,
+		Speed:          o.Speed,
+		Palette:        o.Palette,
+		StopColors:     o.StopColors,
+	}
+}
+
+func newFade(colors chan *color.RGBA, o makerOptions) frame.Maker {
+	return &frame.LinearGradientTransition{
+		ColorChannel:   colors,
+		Transition:     o.Transition,
+		Pairing:        o.Pairing,
+		DwellFrames:    o.DwellFrames,
+		MaxDelta:       o.MaxDelta,
+		ImageWidth:     o.Width,
+		ImageHeight:    o.Height,
+		TemporalDither: o.TemporalDither,
+		Premultiply:    o.Premultiply,
+		MixSpace:       o.MixSpace,
+		HueRotate:      o.HueRotate,
+		Easing:         o.Easing,
+	}
+}
+
+func newPulse(colors chan *color.RGBA, o makerOptions) frame.Maker {
+	return &frame.RadialGradient{
+		ColorChannel: colors,
+		Transition:   o.Transition,
+		Rect:         image.Rect(0, 0, o.Width, o.Height),
+		MixSpace:     o.MixSpace,
+	}
+}
+
+func newStops(colors chan *color.RGBA, o makerOptions) frame.Maker {
+	return &frame.MultiStopGradient{
+		ColorChannel: colors,
+		Transition:   o.Transition,
+		Rect:         image.Rect(0, 0, o.Width, o.Height),
+		Stops:        o.Stops,
+		MixSpace:     o.MixSpace,
+		Smooth:       o.Smooth,
+	}
+}
+
+// Flags an effect's maker has no use for, which are rejected rather than silently
+// ignored when they're set
+var unusedOptions = map[frame.Effect][]string{
+	frame.EffectPulse: {"premultiply", "temporal-dither", "dither", "hue-rotate", "easing", "grain", "smooth", "roi", "angle", "scroll-speed", "stop-colors"},
+	frame.EffectStops: {"premultiply", "temporal-dither", "dither", "hue-rotate", "easing", "grain", "roi", "angle", "scroll-speed", "stop-colors"},
+}
+
+// Returns the flags set in conf which the effect doesn't use
+func unusedFlags(effect frame.Effect, conf *config.Config) []string {
+	set := map[string]bool{
+		"premultiply":     conf.Premultiply,
+		"temporal-dither": conf.TemporalDither,
+		"dither":          conf.Dither,
+		"hue-rotate":      conf.HueRotate != 0,
+		"easing":          conf.Easing != "" && conf.Easing != "linear",
+		"grain":           conf.Grain != 0,
+		"smooth":          conf.Smooth,
+		"roi":             conf.ROI != "",
+		"angle":           conf.Angle != 0,
+		"scroll-speed":    conf.ScrollSpeed != 0,
+		"stop-colors":     len(conf.StopColors) > 0,
+	}
+	var unused []string
+	for _, name := range unusedOptions[effect] {
+		if set[name] {
+			unused = append(unused, name)
+		}
+	}
+	return unused
+}
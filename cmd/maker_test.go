@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/broganross/color-run/internal/config"
+	"github.com/broganross/color-run/internal/frame"
+)
+
+func makerFrame(t *testing.T, effect frame.Effect, n int) []byte {
+	t.Helper()
+	colors := make(chan *color.RGBA, 4)
+	colors <- &color.RGBA{R: 255, A: 255}
+	colors <- &color.RGBA{G: 255, A: 255}
+	colors <- &color.RGBA{B: 255, A: 255}
+	close(colors)
+	m := newFrameMaker(colors, makerOptions{Effect: effect, Width: 8, Height: 2, Transition: 4, Stops: 2})
+	if got, want := m.Bounds(), image.Rect(0, 0, 8, 2); got != want {
+		t.Fatalf("%s: got bounds %v, want %v", effect, got, want)
+	}
+	if got := m.PixelFormat(); got != "rgba" {
+		t.Fatalf("%s: got pixel format %q, want rgba", effect, got)
+	}
+	go m.Run()
+	defer m.Close()
+	buf := make([]byte, 8*2*4)
+	for i := 0; i <= n; i++ {
+		if _, err := io.ReadFull(m, buf); err != nil {
+			t.Fatalf("%s: reading frame %d: %v", effect, i, err)
+		}
+	}
+	return buf
+}
+
+func TestNewFrameMaker(t *testing.T) {
+	// part way into the transition the fade is one color and the scroll still spans two
+	fade := makerFrame(t, frame.EffectFade, 1)
+	for i := 4; i < len(fade); i += 4 {
+		if !bytes.Equal(fade[i:i+4], fade[:4]) {
+			t.Fatalf("fade: pixel %d is %v, want solid %v", i/4, fade[i:i+4], fade[:4])
+		}
+	}
+	scroll := makerFrame(t, frame.EffectScroll, 1)
+	if bytes.Equal(scroll[:4], scroll[7*4:8*4]) {
+		t.Errorf("scroll: first and last pixel are both %v, want them to differ", scroll[:4])
+	}
+}
+
+func TestFrameMakersCoverEffects(t *testing.T) {
+	if len(frameMakers) != len(frame.Effects) {
+		t.Errorf("got %d frame makers for %d effects", len(frameMakers), len(frame.Effects))
+	}
+	for _, effect := range frame.Effects {
+		if _, ok := frameMakers[effect]; !ok {
+			t.Errorf("no frame maker for effect %q", effect)
+			continue
+		}
+		makerFrame(t, effect, 1)
+	}
+}
+
+func TestFrameMakersKeepAlpha(t *testing.T) {
+	const alpha = 128
+	for _, effect := range frame.Effects {
+		colors := make(chan *color.RGBA, 4)
+		colors <- &color.RGBA{R: 255, A: alpha}
+		colors <- &color.RGBA{G: 255, A: alpha}
+		colors <- &color.RGBA{B: 255, A: alpha}
+		close(colors)
+		m := newFrameMaker(colors, makerOptions{Effect: effect, Width: 8, Height: 2, Transition: 4, Stops: 2})
+		go m.Run()
+		out, err := io.ReadAll(m)
+		m.Close()
+		if err != nil {
+			t.Fatalf("%s: reading: %s", effect, err)
+		}
+		if len(out) == 0 {
+			t.Fatalf("%s: got no frames", effect)
+		}
+		for i := 3; i < len(out); i += 4 {
+			if out[i] != alpha {
+				t.Fatalf("%s: pixel %d has alpha %d, want %d", effect, i/4, out[i], alpha)
+			}
+		}
+	}
+}
+
+func TestUnusedFlags(t *testing.T) {
+	conf := &config.Config{Easing: "linear", Dither: true, ROI: "0,0,4,4", Grain: 2}
+	if got, want := unusedFlags(frame.EffectPulse, conf), []string{"dither", "grain", "roi"}; !slices.Equal(got, want) {
+		t.Errorf("pulse got unused flags %v, want %v", got, want)
+	}
+	// stops smooths its gradient too
+	conf.Smooth, conf.Easing = true, "ease-in-out"
+	if got, want := unusedFlags(frame.EffectStops, conf), []string{"dither", "easing", "grain", "roi"}; !slices.Equal(got, want) {
+		t.Errorf("stops got unused flags %v, want %v", got, want)
+	}
+	if got := unusedFlags(frame.EffectScroll, conf); len(got) > 0 {
+		t.Errorf("scroll got unused flags %v, want none", got)
+	}
+	// the defaults are never unused
+	if got := unusedFlags(frame.EffectPulse, &config.Config{Easing: "linear"}); len(got) > 0 {
+		t.Errorf("pulse got unused flags %v with the defaults", got)
+	}
+}
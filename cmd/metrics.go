@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/broganross/color-run/internal/colormind"
+	"github.com/broganross/color-run/internal/frame"
+	"github.com/rs/zerolog/log"
+)
+
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value float64
+}
+
+// Serves the same numbers as /stats, and a few more, in Prometheus' text format.
+// Written by hand so the client library isn't a dependency.
+type metricsHandler struct {
+	frames *frame.Counter
+	queue  *colormind.QueueStats
+	// Number of colors waiting to be shown
+	queueDepth func() int
+	restarts   atomic.Int64
+}
+
+// Counts an output restart, suitable as Supervisor.OnRestart
+func (mh *metricsHandler) restarted() {
+	mh.restarts.Add(1)
+}
+
+func (mh *metricsHandler) metrics() []metric {
+	fetched, failed := mh.queue.PalettesFetched(), mh.queue.PaletteErrors()
+	return []metric{
+		{"frames_produced_total", "counter", "Frames produced.", float64(mh.frames.Frames())},
+		{"palette_requests_total", "counter", "Palette requests made, after any retries.", float64(fetched + failed)},
+		{"palette_errors_total", "counter", "Palette requests which failed, after any retries.", float64(failed)},
+		{"current_fps", "gauge", "Frames produced per second over the last second or so.", mh.frames.FPS()},
+		{"color_queue_depth", "gauge", "Colors waiting to be shown.", float64(mh.queueDepth())},
+		{"ffmpeg_restarts_total", "counter", "Times the output was restarted after failing.", float64(mh.restarts.Load())},
+	}
+}
+
+func (mh *metricsHandler) write(w io.Writer) error {
+	for _, m := range mh.metrics() {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mh *metricsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := mh.write(w); err != nil {
+		log.Error().Err(err).Msg("writing metrics response")
+	}
+}
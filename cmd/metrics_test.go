@@ -0,0 +1,57 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/broganross/color-run/internal/colormind"
+	"github.com/broganross/color-run/internal/frame"
+)
+
+func TestMetrics(t *testing.T) {
+	colors := make(chan *color.RGBA, 8)
+	for i := 0; i < cap(colors); i++ {
+		colors <- &color.RGBA{R: uint8(i * 30), A: 255}
+	}
+	close(colors)
+	lg := &frame.LinearGradient{ColorChannel: colors, Transition: 2, Rect: image.Rect(0, 0, 4, 1)}
+	lg.Reset()
+	go lg.Run()
+	defer lg.Close()
+	counter := &frame.Counter{R: lg, FrameSize: 4 * 4}
+	if _, err := io.CopyN(io.Discard, counter, 3*4*4); err != nil {
+		t.Fatal(err)
+	}
+	mh := &metricsHandler{
+		frames:     counter,
+		queue:      &colormind.QueueStats{},
+		queueDepth: func() int { return len(colors) },
+	}
+	mh.restarted()
+
+	rec := httptest.NewRecorder()
+	mh.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"frames_produced_total 3\n",
+		"palette_requests_total 0\n",
+		"palette_errors_total 0\n",
+		"current_fps ",
+		"color_queue_depth ",
+		"ffmpeg_restarts_total 1\n",
+		"# TYPE frames_produced_total counter\n",
+		"# TYPE current_fps gauge\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics are missing %q:\n%s", want, body)
+		}
+	}
+}
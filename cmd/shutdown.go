@@ -0,0 +1,38 @@
+package main
+
+import "time"
+
+// Returns a channel closed once ch has been closed, discarding whatever is left in
+// it.  A nil ch is taken as already closed.
+func drained[T any](ch <-chan T) <-chan struct{} {
+	done := make(chan struct{})
+	if ch == nil {
+		close(done)
+		return done
+	}
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}
+
+// Waits for every channel to be closed, or for the timeout when it's above 0.
+// Reports whether they all were.
+func waitClosed(timeout time.Duration, chans ...<-chan struct{}) bool {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for _, ch := range chans {
+		select {
+		case <-ch:
+		case <-expired:
+			return false
+		}
+	}
+	return true
+}
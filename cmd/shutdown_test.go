@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitClosed(t *testing.T) {
+	colors := make(chan int, 3)
+	colors <- 1
+	colors <- 2
+	close(colors)
+	finished := make(chan struct{})
+	close(finished)
+	// leftovers are discarded and a nil channel counts as closed
+	var errs chan error
+	if !waitClosed(time.Second, drained(colors), drained(errs), finished) {
+		t.Error("timed out waiting on closed channels")
+	}
+	open := make(chan int)
+	start := time.Now()
+	if waitClosed(20*time.Millisecond, finished, drained(open)) {
+		t.Error("an open channel counted as closed")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s to time out after 20ms", elapsed)
+	}
+	close(open)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"os"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Exit code when a second signal skips the graceful shutdown
+const exitForced = 130
+
+// The first signal starts a graceful shutdown with cancel.  A second means whoever sent
+// it doesn't want to wait, so force is called to exit straight away.
+func handleSignals(sigs <-chan os.Signal, cancel func(), force func()) {
+	sig, ok := <-sigs
+	if !ok {
+		return
+	}
+	log.Info().Str("signal", sig.String()).Msg("shutting down, signal again to force it")
+	cancel()
+	sig, ok = <-sigs
+	if !ok {
+		return
+	}
+	log.Warn().Str("signal", sig.String()).Msg("forcing shutdown")
+	force()
+}
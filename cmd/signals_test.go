@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignals(t *testing.T) {
+	sigs := make(chan os.Signal, 2)
+	cancelled := make(chan struct{})
+	forced := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		handleSignals(sigs, func() { close(cancelled) }, func() { close(forced) })
+		close(done)
+	}()
+	sigs <- syscall.SIGTERM
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the first signal didn't cancel")
+	}
+	select {
+	case <-forced:
+		t.Fatal("the first signal forced the shutdown")
+	case <-time.After(10 * time.Millisecond):
+	}
+	sigs <- syscall.SIGINT
+	select {
+	case <-forced:
+	case <-time.After(time.Second):
+		t.Fatal("the second signal didn't force the shutdown")
+	}
+	<-done
+}
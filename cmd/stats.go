@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/broganross/color-run/internal/colormind"
+	"github.com/broganross/color-run/internal/frame"
+	"github.com/rs/zerolog/log"
+)
+
+type statsResponse struct {
+	FramesProduced  int64   `json:"frames_produced"`
+	CurrentFPS      float64 `json:"current_fps"`
+	PalettesFetched int64   `json:"palettes_fetched"`
+	PaletteErrors   int64   `json:"palette_errors"`
+}
+
+// Serves how the pipeline is doing as JSON, so a starved color queue shows up as
+// the frame rate dropping
+type statsHandler struct {
+	frames *frame.Counter
+	queue  *colormind.QueueStats
+}
+
+func (sh *statsHandler) get() statsResponse {
+	return statsResponse{
+		FramesProduced:  sh.frames.Frames(),
+		CurrentFPS:      sh.frames.FPS(),
+		PalettesFetched: sh.queue.PalettesFetched(),
+		PaletteErrors:   sh.queue.PaletteErrors(),
+	}
+}
+
+func (sh *statsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sh.get()); err != nil {
+		log.Error().Err(err).Msg("writing stats response")
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"io"
 	"net/http"
 	"slices"
+	"sync"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
@@ -25,13 +27,22 @@ var (
 	ErrEmptyBody      = errors.New("response has empty body")
 	ErrValidation     = errors.New("validation error")
 	ErrEmptyPalette   = errors.New("palette may not be empty")
+	ErrEmptyColor     = errors.New("color is an empty slot")
+
+	// Models colormind always serves, the rest change daily
+	BundledModels = []string{"default", "ui"}
 
 	emptyBytes = [...]byte{101, 109, 112, 116, 121, 32, 98, 111, 100, 121, 10}
 )
 
+// A color as colormind writes it, [r, g, b].  "N" marks a slot for colormind to
+// fill, which decodes to ErrEmptyColor.
 type Color color.RGBA
 
-func (c *Color) Unmarshal(v []byte) error {
+func (c *Color) UnmarshalJSON(v []byte) error {
+	if bytes.Equal(bytes.TrimSpace(v), []byte(`"N"`)) {
+		return ErrEmptyColor
+	}
 	var values [3]uint8
 	if err := json.Unmarshal(v, &values); err != nil {
 		return err
@@ -43,24 +54,44 @@ func (c *Color) Unmarshal(v []byte) error {
 	return nil
 }
 
-func (c *Color) Marshal() ([]byte, error) {
+func (c Color) MarshalJSON() ([]byte, error) {
 	values := [3]uint8{c.R, c.G, c.B}
 	return json.Marshal(values)
 }
 
 type Palette [5]*color.RGBA
 
+// Slots holding "N", which colormind uses for colors it's to fill, are left nil so
+// a locked input palette round trips
 func (p *Palette) UnmarshalJSON(b []byte) error {
-	values := [5][3]uint8{}
+	values := [5]json.RawMessage{}
 	if err := json.Unmarshal(b, &values); err != nil {
 		return err
 	}
-	for i := 0; i < 5; i++ {
-		p[i] = &color.RGBA{
-			values[i][0],
-			values[i][1],
-			values[i][2],
-			255,
+	for i, v := range values {
+		c := Color{}
+		switch err := c.UnmarshalJSON(v); {
+		case errors.Is(err, ErrEmptyColor):
+			p[i] = nil
+		case err != nil:
+			return fmt.Errorf("color %d: %w", i, err)
+		default:
+			p[i] = (*color.RGBA)(&c)
+		}
+	}
+	return nil
+}
+
+func isNil(c *color.RGBA) bool {
+	return c == nil
+}
+
+// Returns an error wrapping ErrEmptyColor for the first slot left nil, as a palette
+// colormind returns or one read from a file must have every color filled
+func (p *Palette) filled() error {
+	for i, c := range p {
+		if c == nil {
+			return fmt.Errorf("color %d: %w", i, ErrEmptyColor)
 		}
 	}
 	return nil
@@ -89,15 +120,83 @@ func (p *Palette) MarshalJSON() ([]byte, error) {
 	return out, nil
 }
 
+// What PaletteQueue needs from colormind, satisfied by ColorMind and FakeClient
+type PaletteClient interface {
+	GetPaletteWithContext(ctx context.Context, model string, p *Palette) (*Palette, error)
+	ListModelsWithContext(ctx context.Context) ([]string, error)
+}
+
 type ColorMind struct {
 	URL    string
 	Client *http.Client
+	// Paths palettes are requested from and models listed at, relative to URL.  Empty
+	// paths use colormind's own.
+	APIPath  string
+	ListPath string
+	// Maximum number of requests in flight at once, shared by every caller.  Defaults to 1.
+	// Must be set before the first request.
+	MaxConcurrent int
+	// When set ListModels returns these instead of asking the API
+	CachedModels []string
+	// How long a single request may take, DefaultTimeout when 0.  A shorter deadline on
+	// the caller's context still wins.
+	Timeout time.Duration
+	// Retries transient palette request failures, the zero value doesn't
+	Retry RetryConfig
+	// How long ListModels reuses the models it was last given, 0 asks every time
+	ModelCacheTTL time.Duration
+	models        modelCache
+	semOnce       sync.Once
+	sem           chan struct{}
+}
+
+const (
+	DefaultAPIPath  = "/api/"
+	DefaultListPath = "/list"
+	DefaultTimeout  = 5 * time.Second
+	// The models only change daily
+	DefaultModelCacheTTL = time.Hour
+)
+
+func orDefault(path string, def string) string {
+	if path == "" {
+		return def
+	}
+	return path
+}
+
+func (c *ColorMind) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return c.Timeout
 }
 
 func New() *ColorMind {
 	return &ColorMind{
-		URL:    "http://colormind.io",
-		Client: http.DefaultClient,
+		URL:           "http://colormind.io",
+		Client:        http.DefaultClient,
+		APIPath:       DefaultAPIPath,
+		ListPath:      DefaultListPath,
+		MaxConcurrent: 1,
+		ModelCacheTTL: DefaultModelCacheTTL,
+	}
+}
+
+// Waits for a free request slot, returning the function to release it
+func (c *ColorMind) acquire(ctx context.Context) (func(), error) {
+	c.semOnce.Do(func() {
+		size := c.MaxConcurrent
+		if size < 1 {
+			size = 1
+		}
+		c.sem = make(chan struct{}, size)
+	})
+	select {
+	case c.sem <- struct{}{}:
+		return func() { <-c.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
 
@@ -105,9 +204,10 @@ func (c *ColorMind) GetPalette(model string, p *Palette) (*Palette, error) {
 	return c.GetPaletteWithContext(context.Background(), model, p)
 }
 
+// Requests a palette from the model, seeded with the colors of p when it isn't nil.
+// Transient failures are retried as configured by Retry, for as long as the context
+// allows.
 func (c *ColorMind) GetPaletteWithContext(ctx context.Context, model string, p *Palette) (*Palette, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
 	// if given a palette it must contain at least one color
 	if p != nil {
 		cnt := 0
@@ -120,6 +220,29 @@ func (c *ColorMind) GetPaletteWithContext(ctx context.Context, model string, p *
 			return nil, ErrEmptyPalette
 		}
 	}
+	for attempt := 1; ; attempt++ {
+		pal, err := c.getPalette(ctx, model, p)
+		if err == nil || attempt >= c.Retry.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return pal, err
+		}
+		log.Debug().Err(err).Int("attempt", attempt).Msg("retrying palette request")
+		select {
+		case <-time.After(c.Retry.delay(attempt)):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+	}
+}
+
+// Makes a single palette request
+func (c *ColorMind) getPalette(ctx context.Context, model string, p *Palette) (*Palette, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout())
+	defer cancel()
 	opts := &getPaletteRequest{
 		Model: model,
 		Input: p,
@@ -129,7 +252,7 @@ func (c *ColorMind) GetPaletteWithContext(ctx context.Context, model string, p *
 		return nil, fmt.Errorf("marshaling request body: %w", err)
 	}
 	body := bytes.NewBuffer(contents)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/api/", c.URL), body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+orDefault(c.APIPath, DefaultAPIPath), body)
 	if err != nil {
 		return nil, fmt.Errorf("making request: %w", err)
 	}
@@ -150,7 +273,7 @@ func (c *ColorMind) GetPaletteWithContext(ctx context.Context, model string, p *
 			}
 			contents = string(b)
 		}
-		return nil, fmt.Errorf("%w (%s): %s", ErrResponseStatus, http.StatusText(resp.StatusCode), contents)
+		return nil, &StatusError{Code: resp.StatusCode, Body: contents}
 	}
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -163,6 +286,9 @@ func (c *ColorMind) GetPaletteWithContext(ctx context.Context, model string, p *
 	if err := json.Unmarshal(b, &respOpts); err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrParseBody, err)
 	}
+	if err := respOpts.Result.filled(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseBody, err)
+	}
 	return &respOpts.Result, nil
 }
 
@@ -170,10 +296,33 @@ func (c *ColorMind) ListModels() ([]string, error) {
 	return c.ListModelsWithContext(context.Background())
 }
 
+// Lists the models colormind is serving today.  Within ModelCacheTTL of the last
+// successful request they're answered from memory, and concurrent callers share one
+// request.
 func (c *ColorMind) ListModelsWithContext(ctx context.Context) ([]string, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	if c.CachedModels != nil {
+		return slices.Clone(c.CachedModels), nil
+	}
+	if c.ModelCacheTTL <= 0 {
+		return c.listModels(ctx)
+	}
+	return c.models.get(ctx, c.ModelCacheTTL, c.listModels)
+}
+
+// Forgets the cached models so the next ListModels asks the API
+func (c *ColorMind) ClearModelCache() {
+	c.models.clear()
+}
+
+func (c *ColorMind) listModels(ctx context.Context) ([]string, error) {
+	release, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(ctx, c.timeout())
 	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/list", c.URL), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL+orDefault(c.ListPath, DefaultListPath), nil)
 	if err != nil {
 		return nil, fmt.Errorf("making request: %w", err)
 	}
@@ -196,7 +345,7 @@ func (c *ColorMind) ListModelsWithContext(ctx context.Context) ([]string, error)
 			}
 			contents = string(b)
 		}
-		return nil, fmt.Errorf("%w (%s): %s", ErrResponseStatus, http.StatusText(resp.StatusCode), contents)
+		return nil, &StatusError{Code: resp.StatusCode, Body: contents}
 	}
 	results := listModelResponse{}
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
@@ -205,42 +354,179 @@ func (c *ColorMind) ListModelsWithContext(ctx context.Context) ([]string, error)
 	return results.Result, nil
 }
 
-func PaletteQueue(ctx context.Context, model string, cm *ColorMind, chanSize int) (chan *color.RGBA, chan error) {
-	start := 0
-	slowCount := chanSize / 3
-	var previous *Palette
+type QueueOptions struct {
+	// Number of colors buffered by the queue
+	Size int
+	// Applied in order to the new colors of every palette before they are queued
+	Filters []Filter
+	// Called with every palette fetched and the model it came from, before it's filtered
+	OnPalette func(model string, pal *Palette)
+	// Fetch every Nth palette without seeding it, so long runs don't drift into one
+	// region of colors.  0 always seeds.
+	ResetSeedEvery int
+	// Receives the fetch lifecycle events, defaults to the global logger
+	Logger *zerolog.Logger
+	// Queued in full before anything is fetched, so the first frames don't wait on
+	// the network
+	Preload []*Palette
+	// Continuous when empty
+	FetchMode FetchMode
+	// How often a waiting queue checks whether its colors have been read, defaults
+	// to a second
+	DrainPoll time.Duration
+	// Counts the fetches when set
+	Stats *QueueStats
+	// Input of the first request, ie. colors locked at the start to begin the stream
+	// on.  Every color of the first palette is queued, including the seed's.
+	Seed *Palette
+}
+
+// Fetches palettes and queues their colors until the context is done, at which point
+// both returned channels are closed.  Failed requests are sent on the error channel
+// and tried again after a pause.  In continuous mode the queue is filled, then left
+// until fewer than a third of its colors are left before it's filled again.
+//
+// Each request after the first is seeded with the last two colors queued, locked at
+// the start of the palette, so consecutive palettes flow into each other.  Those two
+// colors come back at the start of the response and aren't queued again.  The first
+// request is seeded with opts.Seed, or the end of the preloaded palettes, when set.
+func PaletteQueue(ctx context.Context, model *Model, client PaletteClient, opts QueueOptions) (chan *color.RGBA, chan error) {
+	// colors kept at the start of the next request, nil for an unseeded one
+	var seed map[int]*color.RGBA
 	stop := false
 	errorChannel := make(chan error, 5)
-	colorChannel := make(chan *color.RGBA, chanSize)
+	colorChannel := make(chan *color.RGBA, opts.Size)
+	logger := opts.Logger
+	if logger == nil {
+		logger = &log.Logger
+	}
+	// filters and queues the colors of the palette from start, then seeds the next
+	// request with the last of them
+	queue := func(pal *Palette, start int, fetch int) {
+		// other clients may leave slots empty, which there's nothing to show for
+		colors := slices.DeleteFunc(append([]*color.RGBA{}, pal[start:]...), isNil)
+		for _, f := range opts.Filters {
+			colors = f(colors)
+		}
+		for i, c := range colors {
+			select {
+			case colorChannel <- c:
+				logger.Trace().Str("event", EventColorQueued).Int("fetch", fetch).Int("index", i).Any("color", c).Send()
+			case <-ctx.Done():
+				stop = true
+			}
+		}
+		logger.Debug().Str("event", EventPaletteBoundary).Int("fetch", fetch).Int("colors", len(colors)).Send()
+		// colormind carries on from colors locked at the start of the palette
+		last := colors
+		if len(last) < 2 {
+			last = slices.DeleteFunc(append([]*color.RGBA{}, pal[:]...), isNil)
+		}
+		seed = nil
+		if len(last) >= 2 {
+			seed = map[int]*color.RGBA{0: last[len(last)-2], 1: last[len(last)-1]}
+		}
+	}
+	drainPoll := opts.DrainPoll
+	if drainPoll <= 0 {
+		drainPoll = time.Second
+	}
+	// waits for fewer than n colors to be left in the queue
+	waitBelow := func(n int) {
+		for len(colorChannel) >= n && !stop {
+			select {
+			case <-time.After(drainPoll):
+			case <-ctx.Done():
+				stop = true
+			}
+		}
+	}
+	// continuous mode refills once the queue drops below a third, so requests track
+	// how fast the colors are read rather than running ahead of a slow or paused stream
+	lowWater := max(opts.Size/3, 1)
+	filling := true
+	// the caller's seed, until a request with it succeeds
+	firstSeed := opts.Seed
 	go func() {
-		for {
-			pal, err := cm.GetPaletteWithContext(ctx, model, previous)
-			if err != nil {
-				errorChannel <- fmt.Errorf("getting palette: %w", err)
+		for _, pal := range opts.Preload {
+			if stop {
+				break
+			}
+			if opts.OnPalette != nil {
+				opts.OnPalette("preload", pal)
+			}
+			// preloaded palettes weren't seeded from each other so all their colors are new
+			queue(pal, 0, -1)
+		}
+		if len(colorChannel) == cap(colorChannel) {
+			filling = false
+		}
+		for fetches := 0; !stop; fetches++ {
+			if opts.FetchMode == FetchOnDemand {
+				waitBelow(1)
+				if stop {
+					break
+				}
+			} else if !filling {
+				waitBelow(lowWater)
+				if stop {
+					break
+				}
+				filling = true
+			}
+			if opts.ResetSeedEvery > 0 && fetches > 0 && fetches%opts.ResetSeedEvery == 0 {
+				seed = nil
+			}
+			// a seeded palette starts with the seed, which has already been queued
+			start := 2
+			if seed == nil {
+				start = 0
+			}
+			modelName := model.Get()
+			logger.Debug().Str("event", EventFetchStart).Int("fetch", fetches).Str("model", modelName).Bool("seeded", seed != nil).Send()
+			fetchStart := time.Now()
+			var input *Palette
+			if seed != nil {
+				// the seed always holds the two colors last queued
+				input, _ = lockedPalette(seed)
 			}
-			log.Debug().Any("palette", pal).Msg("got palette")
-			for i := start; i < len(pal); i++ {
+			if firstSeed != nil {
+				// none of the caller's seed has been queued yet
+				input, start = firstSeed, 0
+			}
+			pal, err := client.GetPaletteWithContext(ctx, modelName, input)
+			if err != nil {
+				logger.Warn().Str("event", EventFetchFailure).Int("fetch", fetches).Str("model", modelName).Str("category", errorCategory(err)).Err(err).Send()
+				if opts.Stats != nil {
+					opts.Stats.errors.Add(1)
+				}
 				select {
-				case colorChannel <- pal[i]:
+				case errorChannel <- fmt.Errorf("getting palette: %w", err):
 				case <-ctx.Done():
-					stop = true
 				}
+				// wait a moment before trying again rather than hammering the API
+				select {
+				case <-time.After(2 * time.Second):
+					continue
+				case <-ctx.Done():
+				}
+				break
 			}
-			if previous == nil {
-				previous = &Palette{}
-				start = 2
+			logger.Debug().Str("event", EventFetchSuccess).Int("fetch", fetches).Str("model", modelName).Dur("duration", time.Since(fetchStart)).Any("palette", pal).Send()
+			if opts.Stats != nil {
+				opts.Stats.palettes.Add(1)
 			}
-			previous[0] = pal[3]
-			previous[1] = pal[4]
-			if slowCount > 0 {
-				time.Sleep(2 * time.Second)
-				slowCount--
+			if opts.OnPalette != nil {
+				opts.OnPalette(modelName, pal)
 			}
-			if stop {
-				break
+			queue(pal, start, fetches)
+			firstSeed = nil
+			if len(colorChannel) == cap(colorChannel) {
+				filling = false
 			}
 		}
 		close(colorChannel)
+		close(errorChannel)
 	}()
 	return colorChannel, errorChannel
 
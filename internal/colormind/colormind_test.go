@@ -0,0 +1,269 @@
+package colormind
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type testRequest struct {
+	Model string            `json:"model"`
+	Input []json.RawMessage `json:"input"`
+}
+
+// Records the palette requests made to a test server and the palettes it answered with
+type paletteRecorder struct {
+	mu        sync.Mutex
+	requests  []testRequest
+	responses [][5][3]uint8
+}
+
+func (pr *paletteRecorder) Requests() ([]testRequest, [][5][3]uint8) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return append([]testRequest{}, pr.requests...), append([][5][3]uint8{}, pr.responses...)
+}
+
+// Starts a server answering every palette request with a distinct palette
+func newPaletteServer(t *testing.T) (*httptest.Server, *paletteRecorder) {
+	t.Helper()
+	pr := &paletteRecorder{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := testRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		pr.mu.Lock()
+		n := uint8(len(pr.requests))
+		pal := [5][3]uint8{}
+		for i := range pal {
+			pal[i] = [3]uint8{n, uint8(i), 255 - n}
+		}
+		pr.requests = append(pr.requests, req)
+		pr.responses = append(pr.responses, pal)
+		pr.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"result": pal})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, pr
+}
+
+func colorJSON(c [3]uint8) string {
+	return fmt.Sprintf("[%d,%d,%d]", c[0], c[1], c[2])
+}
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+func TestPaletteQueueCancel(t *testing.T) {
+	srv, _ := newPaletteServer(t)
+	cm := New()
+	cm.URL = srv.URL
+	base := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	colors, errs := PaletteQueue(ctx, NewModel("default"), cm, QueueOptions{Size: 2})
+	<-colors
+	cancel()
+	deadline := time.After(2 * time.Second)
+	for colors != nil || errs != nil {
+		select {
+		case _, ok := <-colors:
+			if !ok {
+				colors = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		case <-deadline:
+			t.Fatal("channels were not closed after cancelling")
+		}
+	}
+	srv.CloseClientConnections()
+	cm.Client.CloseIdleConnections()
+	for runtime.NumGoroutine() > base {
+		select {
+		case <-deadline:
+			t.Fatalf("%d goroutines leaked", runtime.NumGoroutine()-base)
+		default:
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	for _, limit := range []int{1, 3} {
+		t.Run(fmt.Sprint(limit), func(t *testing.T) {
+			var mu sync.Mutex
+			current, most := 0, 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				current++
+				most = max(most, current)
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				current--
+				mu.Unlock()
+				w.Write([]byte(`{"result":[[1,2,3],[1,2,3],[1,2,3],[1,2,3],[1,2,3]]}`))
+			}))
+			defer srv.Close()
+			cm := New()
+			cm.URL = srv.URL
+			cm.MaxConcurrent = limit
+			wg := sync.WaitGroup{}
+			for i := 0; i < 12; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := cm.GetPalette("default", nil); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+			if most > limit {
+				t.Errorf("saw %d concurrent requests, want at most %d", most, limit)
+			}
+			if limit > 1 && most < 2 {
+				t.Errorf("saw %d concurrent requests, the cap of %d was never used", most, limit)
+			}
+		})
+	}
+}
+
+func TestCachedModels(t *testing.T) {
+	var lists atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/list" {
+			lists.Add(1)
+			json.NewEncoder(w).Encode(map[string]any{"result": []string{"default"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": [5][3]uint8{}})
+	}))
+	defer srv.Close()
+	cm := New()
+	cm.URL = srv.URL
+	cm.CachedModels = BundledModels
+	models, err := cm.ListModels()
+	if err != nil {
+		t.Fatalf("listing models: %s", err)
+	}
+	if _, err := FilterModels(models, []string{"ui"}); err != nil {
+		t.Errorf("pinned model: %s", err)
+	}
+	if _, err := cm.GetPalette("ui", nil); err != nil {
+		t.Fatalf("getting palette: %s", err)
+	}
+	if n := lists.Load(); n != 0 {
+		t.Errorf("made %d /list requests, want 0", n)
+	}
+}
+
+func TestPaletteQueueResetSeedEvery(t *testing.T) {
+	srv, rec := newPaletteServer(t)
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	colors, _ := PaletteQueue(ctx, NewModel("default"), cm, QueueOptions{Size: 2, ResetSeedEvery: 3, DrainPoll: time.Millisecond})
+	deadline := time.After(2 * time.Second)
+	for {
+		if reqs, _ := rec.Requests(); len(reqs) >= 7 {
+			break
+		}
+		select {
+		case <-colors:
+		case <-deadline:
+			t.Fatal("timed out waiting for palettes")
+		}
+	}
+	reqs, _ := rec.Requests()
+	for i, req := range reqs[:7] {
+		unseeded := len(req.Input) == 0
+		if want := i%3 == 0; unseeded != want {
+			t.Errorf("request %d unseeded %t, want %t", i, unseeded, want)
+		}
+	}
+}
+
+func TestEndpointPaths(t *testing.T) {
+	var mu sync.Mutex
+	paths := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/colormind/palette":
+			json.NewEncoder(w).Encode(map[string]any{"result": [5][3]uint8{}})
+		case "/colormind/models":
+			json.NewEncoder(w).Encode(map[string]any{"result": []string{"default"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	cm := New()
+	cm.URL = srv.URL
+	cm.APIPath = "/colormind/palette"
+	cm.ListPath = "/colormind/models"
+	if _, err := cm.GetPalette("default", nil); err != nil {
+		t.Errorf("getting palette: %s", err)
+	}
+	if _, err := cm.ListModels(); err != nil {
+		t.Errorf("listing models: %s", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 || paths[0] != cm.APIPath || paths[1] != cm.ListPath {
+		t.Errorf("requested %v, want [%s %s]", paths, cm.APIPath, cm.ListPath)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	cm := New()
+	cm.URL = srv.URL
+	cm.Timeout = time.Millisecond
+	if _, err := cm.GetPalette("default", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("getting palette: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := cm.ListModels(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("listing models: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	// the caller's shorter deadline wins over the default
+	cm.Timeout = 0
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := cm.GetPaletteWithContext(ctx, "default", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("caller deadline: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d >= DefaultTimeout {
+		t.Errorf("caller deadline: took %v", d)
+	}
+}
@@ -0,0 +1,92 @@
+package colormind
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+)
+
+type modelRequest struct {
+	Model string `json:"model"`
+}
+
+// Lets the model be changed while streaming.  Only models Client lists, and that are
+// in Allowed when it's set, can be chosen.
+type ModelControl struct {
+	Model   *Model
+	Client  PaletteClient
+	Allowed []string
+}
+
+// Switches to the named model, returning ErrValidation when it isn't available
+func (mc *ModelControl) SetModel(ctx context.Context, name string) error {
+	models, err := mc.models(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(models, name) {
+		return fmt.Errorf("%w: unknown model %q", ErrValidation, name)
+	}
+	log.Info().Str("model", name).Msg("changing color model")
+	mc.Model.Set(name)
+	return nil
+}
+
+func (mc *ModelControl) models(ctx context.Context) ([]string, error) {
+	models, err := mc.Client.ListModelsWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing models: %w", err)
+	}
+	return FilterModels(models, mc.Allowed)
+}
+
+// Serves the current model on GET and changes it to the one in a {"model": "ui"}
+// body on POST.  Unknown models are a 400.
+func (mc *ModelControl) ServeModel(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		body := modelRequest{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := mc.SetModel(req.Context(), body.Model); err != nil {
+			code := http.StatusBadGateway
+			if errors.Is(err, ErrValidation) {
+				code = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(modelRequest{Model: mc.Model.Get()}); err != nil {
+		log.Error().Err(err).Msg("writing model response")
+	}
+}
+
+// Serves the models that can be switched to as JSON
+func (mc *ModelControl) ServeModels(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	models, err := mc.models(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(models); err != nil {
+		log.Error().Err(err).Msg("writing models response")
+	}
+}
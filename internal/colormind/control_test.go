@@ -0,0 +1,61 @@
+package colormind
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestModelControl(t *testing.T) {
+	cm := New()
+	cm.CachedModels = []string{"default", "ui", "makoto_shinkai"}
+	mc := &ModelControl{Model: NewModel("default"), Client: cm}
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mc.ServeModel(rec, httptest.NewRequest(http.MethodPost, "/model", strings.NewReader(body)))
+		return rec
+	}
+
+	rec := post(`{"model": "ui"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", rec.Code, rec.Body)
+	}
+	if got := mc.Model.Get(); got != "ui" {
+		t.Errorf("got model %q, want ui", got)
+	}
+	for _, body := range []string{`{"model": "nope"}`, `{"model": ""}`, `not json`} {
+		if rec := post(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if got := mc.Model.Get(); got != "ui" {
+		t.Errorf("a rejected request changed the model to %q", got)
+	}
+
+	// only allowed models can be listed or chosen
+	mc.Allowed = []string{"default", "ui"}
+	if rec := post(`{"model": "makoto_shinkai"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("disallowed model: got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	rec = httptest.NewRecorder()
+	mc.ServeModels(rec, httptest.NewRequest(http.MethodGet, "/models", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d", rec.Code)
+	}
+	models := []string{}
+	if err := json.NewDecoder(rec.Body).Decode(&models); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+	if !slices.Equal(models, mc.Allowed) {
+		t.Errorf("got models %v, want %v", models, mc.Allowed)
+	}
+
+	rec = httptest.NewRecorder()
+	mc.ServeModels(rec, httptest.NewRequest(http.MethodPost, "/models", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /models: got status %d", rec.Code)
+	}
+}
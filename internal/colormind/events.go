@@ -0,0 +1,31 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+)
+
+// Values of the event field logged through a palette fetch, so downstream tooling can
+// follow the lifecycle without parsing messages
+const (
+	EventFetchStart      = "fetch_start"
+	EventFetchSuccess    = "fetch_success"
+	EventFetchFailure    = "fetch_failure"
+	EventColorQueued     = "color_queued"
+	EventPaletteBoundary = "palette_boundary"
+)
+
+// Groups fetch errors into the category field of a fetch_failure event
+func errorCategory(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+		return "timeout"
+	case errors.Is(err, ErrResponseStatus):
+		return "status"
+	case errors.Is(err, ErrPost) || errors.Is(err, ErrGet):
+		return "network"
+	case errors.Is(err, ErrReadBody) || errors.Is(err, ErrParseBody) || errors.Is(err, ErrEmptyBody):
+		return "body"
+	}
+	return "other"
+}
@@ -0,0 +1,121 @@
+package colormind
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Collects log lines written from another goroutine
+type syncBuffer struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	sb.lines = append(sb.lines, strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (sb *syncBuffer) events() []map[string]any {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	out := []map[string]any{}
+	for _, l := range sb.lines {
+		e := map[string]any{}
+		if json.Unmarshal([]byte(l), &e) == nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestPaletteQueueEvents(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.TraceLevel)
+	defer zerolog.SetGlobalLevel(zerolog.Disabled)
+	srv, _ := newPaletteServer(t)
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	buf := &syncBuffer{}
+	logger := zerolog.New(buf).Level(zerolog.TraceLevel)
+	colors, _ := PaletteQueue(ctx, NewModel("ui"), cm, QueueOptions{Size: 5, Logger: &logger})
+	for i := 0; i < 5; i++ {
+		<-colors
+	}
+	// the boundary is logged after the last color is queued
+	deadline := time.Now().Add(2 * time.Second)
+	var events []map[string]any
+	for {
+		events = buf.events()
+		found := false
+		for _, e := range events {
+			found = found || e["event"] == EventPaletteBoundary
+		}
+		if found {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no palette boundary was logged")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	want := []struct {
+		event  string
+		fields []string
+	}{
+		{EventFetchStart, []string{"fetch", "model", "seeded"}},
+		{EventFetchSuccess, []string{"fetch", "model", "duration", "palette"}},
+		{EventColorQueued, []string{"fetch", "index", "color"}},
+		{EventPaletteBoundary, []string{"fetch", "colors"}},
+	}
+	next := 0
+	queued := 0
+	for _, e := range events {
+		if e["event"] == EventColorQueued {
+			queued++
+		}
+		if next < len(want) && e["event"] == want[next].event {
+			for _, f := range want[next].fields {
+				if _, ok := e[f]; !ok {
+					t.Errorf("%s event is missing %s: %v", want[next].event, f, e)
+				}
+			}
+			if m, ok := e["model"]; ok && m != "ui" {
+				t.Errorf("%s event has model %v, want ui", want[next].event, m)
+			}
+			next++
+		}
+	}
+	if next != len(want) {
+		t.Errorf("only saw the first %d lifecycle events in order: %v", next, events)
+	}
+	if queued != 5 {
+		t.Errorf("got %d color_queued events, want 5", queued)
+	}
+}
+
+func TestErrorCategory(t *testing.T) {
+	tests := map[error]string{
+		fmt.Errorf("%w (%s): x", ErrResponseStatus, http.StatusText(500)): "status",
+		fmt.Errorf("%w: refused", ErrPost):                                "network",
+		fmt.Errorf("%w: bad json", ErrParseBody):                          "body",
+		fmt.Errorf("waiting: %w", context.DeadlineExceeded):               "timeout",
+		ErrEmptyPalette: "other",
+	}
+	for err, want := range tests {
+		if got := errorCategory(err); got != want {
+			t.Errorf("%v: got %s, want %s", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package colormind
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// PaletteClient returning canned palettes, for testing code which uses colormind
+// without a server
+type FakeClient struct {
+	// Returned in turn, starting again from the first once they run out
+	Palettes []*Palette
+	Models   []string
+	// Returned by the palette requests in turn before any palettes are, a nil entry
+	// lets that request succeed
+	Errors []error
+	mu     sync.Mutex
+	inputs []*Palette
+	served int
+}
+
+func (fc *FakeClient) GetPaletteWithContext(ctx context.Context, model string, p *Palette) (*Palette, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	var input *Palette
+	if p != nil {
+		input = &Palette{}
+		*input = *p
+	}
+	fc.inputs = append(fc.inputs, input)
+	if i := len(fc.inputs) - 1; i < len(fc.Errors) && fc.Errors[i] != nil {
+		return nil, fc.Errors[i]
+	}
+	if len(fc.Palettes) == 0 {
+		return nil, ErrEmptyBody
+	}
+	pal := *fc.Palettes[fc.served%len(fc.Palettes)]
+	fc.served++
+	return &pal, nil
+}
+
+func (fc *FakeClient) ListModelsWithContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(fc.Models), nil
+}
+
+// Returns the input palette of every request made so far, nil for unseeded ones
+func (fc *FakeClient) Requests() []*Palette {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return slices.Clone(fc.inputs)
+}
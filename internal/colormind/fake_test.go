@@ -0,0 +1,216 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPaletteQueueFakeClient(t *testing.T) {
+	pal := &Palette{}
+	for i := range pal {
+		pal[i] = &color.RGBA{R: uint8(i + 1), A: 255}
+	}
+	failure := errors.New("colormind is down")
+	fc := &FakeClient{Palettes: []*Palette{pal}, Errors: []error{failure}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stats := &QueueStats{}
+	colors, errs := PaletteQueue(ctx, NewModel("default"), fc, QueueOptions{Size: 5, Stats: stats})
+	select {
+	case err := <-errs:
+		if !errors.Is(err, failure) {
+			t.Errorf("got error %v, want %v", err, failure)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the failure")
+	}
+	// the queue backs off and tries again
+	for i := range pal {
+		select {
+		case c := <-colors:
+			if c != pal[i] {
+				t.Errorf("color %d is %v, want %v", i, c, pal[i])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the palette after the failure")
+		}
+	}
+	reqs := fc.Requests()
+	if len(reqs) < 2 || reqs[0] != nil || reqs[1] != nil {
+		t.Errorf("got requests %v, want the retry to be unseeded like the failure", reqs)
+	}
+	if n := stats.PaletteErrors(); n != 1 {
+		t.Errorf("counted %d palette errors, want 1", n)
+	}
+	if n := stats.PalettesFetched(); n < 1 {
+		t.Errorf("counted %d palettes fetched, want the retry's", n)
+	}
+}
+
+func TestPaletteQueueStopsWhileFull(t *testing.T) {
+	pal := &Palette{}
+	for i := range pal {
+		pal[i] = &color.RGBA{R: uint8(i + 1), A: 255}
+	}
+	fc := &FakeClient{Palettes: []*Palette{pal}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the first palette fills the queue, which then waits for it to be read
+	colors, errs := PaletteQueue(ctx, NewModel("default"), fc, QueueOptions{Size: 5})
+	deadline := time.Now().Add(2 * time.Second)
+	for len(colors) < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	closed := time.After(500 * time.Millisecond)
+	for colors != nil || errs != nil {
+		select {
+		case _, ok := <-colors:
+			if !ok {
+				colors = nil
+			}
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+			}
+		case <-closed:
+			t.Fatal("channels still open after the context was cancelled")
+		}
+	}
+}
+
+func TestPaletteQueueSeed(t *testing.T) {
+	pals := []*Palette{{}, {}}
+	for i, pal := range pals {
+		for j := range pal {
+			pal[j] = &color.RGBA{R: uint8(i*10 + j), A: 255}
+		}
+	}
+	failure := errors.New("colormind is down")
+	fc := &FakeClient{Palettes: pals, Errors: []error{failure}}
+	seed := &Palette{&color.RGBA{G: 1, A: 255}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	colors, _ := PaletteQueue(ctx, NewModel("default"), fc, QueueOptions{Size: 2, Seed: seed})
+	// every color of the first palette, then the new colors of the second
+	want := append(pals[0][:], pals[1][2:]...)
+	for i, w := range want {
+		select {
+		case c := <-colors:
+			if c != w {
+				t.Errorf("color %d is %v, want %v", i, *c, *w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for color %d", i)
+		}
+	}
+	reqs := fc.Requests()
+	if len(reqs) < 3 {
+		t.Fatalf("got %d requests, want 3", len(reqs))
+	}
+	// the failed request and its retry both carry the seed
+	for i := 0; i < 2; i++ {
+		if reqs[i] == nil || reqs[i][0] != seed[0] {
+			t.Errorf("request %d is %v, want the seed", i, reqs[i])
+		}
+	}
+	// then the last two colors queued, locked at the start
+	if reqs[2] == nil || reqs[2][0] != pals[0][3] || reqs[2][1] != pals[0][4] || reqs[2][2] != nil {
+		t.Errorf("request 2 is %v, want it seeded with the end of the first palette", reqs[2])
+	}
+}
+
+func TestPaletteQueueWatermark(t *testing.T) {
+	pal := &Palette{}
+	for i := range pal {
+		pal[i] = &color.RGBA{R: uint8(i + 1), A: 255}
+	}
+	fc := &FakeClient{Palettes: []*Palette{pal}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the first palette queues 5 colors and each after it 3, so 4 fill the queue
+	// exactly, and it's refilled once fewer than 4 are left
+	colors, _ := PaletteQueue(ctx, NewModel("default"), fc, QueueOptions{Size: 14, DrainPoll: time.Millisecond})
+	deadline := time.Now().Add(2 * time.Second)
+	for len(colors) < 14 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := len(fc.Requests()); n != 4 {
+		t.Fatalf("made %d requests filling the queue, want 4", n)
+	}
+	// read slowly down to the low-water mark without anything being fetched
+	for len(colors) > 4 {
+		<-colors
+		time.Sleep(2 * time.Millisecond)
+		if n := len(fc.Requests()); n != 4 {
+			t.Fatalf("made %d requests with %d colors still queued", n, len(colors))
+		}
+	}
+	<-colors
+	deadline = time.Now().Add(2 * time.Second)
+	for len(fc.Requests()) == 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := len(fc.Requests()); n == 4 {
+		t.Error("nothing fetched after the queue dropped below the low-water mark")
+	}
+}
+
+func TestPaletteQueueEmptySlots(t *testing.T) {
+	// colormind answering with a slot left "N" once, then with every color
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Write([]byte(`{"result":[[1,1,1],"N",[3,3,3],[4,4,4],[5,5,5]]}`))
+			return
+		}
+		w.Write([]byte(`{"result":[[1,1,1],[2,2,2],[3,3,3],[4,4,4],[5,5,5]]}`))
+	}))
+	defer srv.Close()
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	filters := []Filter{DropAdjacentDuplicates}
+	colors, errs := PaletteQueue(ctx, NewModel("default"), cm, QueueOptions{Size: 5, Filters: filters})
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrParseBody) {
+			t.Errorf("got error %v, want %v", err, ErrParseBody)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the empty slot to be rejected")
+	}
+	for i := 0; i < 5; i++ {
+		select {
+		case c := <-colors:
+			if c == nil {
+				t.Fatalf("color %d is nil", i)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the retry's colors")
+		}
+	}
+
+	// other clients' empty slots are skipped
+	pal := &Palette{{R: 1, A: 255}, nil, {R: 3, A: 255}, nil, {R: 5, A: 255}}
+	fc := &FakeClient{Palettes: []*Palette{pal}}
+	colors, _ = PaletteQueue(ctx, NewModel("default"), fc, QueueOptions{Size: 3, Filters: filters})
+	for i, want := range []*color.RGBA{pal[0], pal[2], pal[4]} {
+		select {
+		case c := <-colors:
+			if c != want {
+				t.Errorf("color %d is %v, want %v", i, c, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for color %d", i)
+		}
+	}
+}
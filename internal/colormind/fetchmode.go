@@ -0,0 +1,27 @@
+package colormind
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrFetchMode = errors.New("unknown fetch mode")
+
+// When PaletteQueue fetches the next palette
+type FetchMode string
+
+const (
+	// Fill the queue, then refill it once it drops below a third
+	FetchContinuous FetchMode = "continuous"
+	// Fetch once the queued colors have all been read, for ambient streams where a
+	// full buffer isn't worth the requests
+	FetchOnDemand FetchMode = "ondemand"
+)
+
+func ParseFetchMode(s string) (FetchMode, error) {
+	switch mode := FetchMode(s); mode {
+	case FetchContinuous, FetchOnDemand:
+		return mode, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrFetchMode, s)
+}
@@ -0,0 +1,54 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseFetchMode(t *testing.T) {
+	for _, s := range []string{"continuous", "ondemand"} {
+		if mode, err := ParseFetchMode(s); err != nil || string(mode) != s {
+			t.Errorf("ParseFetchMode(%q) = %q, %v", s, mode, err)
+		}
+	}
+	if _, err := ParseFetchMode("eager"); !errors.Is(err, ErrFetchMode) {
+		t.Errorf("got %v, want ErrFetchMode", err)
+	}
+}
+
+func TestPaletteQueueOnDemand(t *testing.T) {
+	srv, rec := newPaletteServer(t)
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	colors, _ := PaletteQueue(ctx, NewModel("default"), cm, QueueOptions{
+		Size:      15,
+		FetchMode: FetchOnDemand,
+		DrainPoll: 5 * time.Millisecond,
+	})
+	fetched := func() int {
+		reqs, _ := rec.Requests()
+		return len(reqs)
+	}
+	<-colors
+	for i := 0; i < 3; i++ {
+		<-colors
+	}
+	// one color of the palette is still queued
+	time.Sleep(100 * time.Millisecond)
+	if n := fetched(); n != 1 {
+		t.Fatalf("got %d fetches before the palette was read, want 1", n)
+	}
+	<-colors
+	deadline := time.After(2 * time.Second)
+	for fetched() < 2 {
+		select {
+		case <-time.After(5 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("timed out waiting for the next fetch once the palette was read")
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package colormind
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/broganross/color-run/internal/colorspace"
+)
+
+var ErrSortMode = errors.New("unknown sort mode")
+
+// Transforms the colors of a palette before they are queued
+type Filter func(colors []*color.RGBA) []*color.RGBA
+
+// Order the colors of a palette are queued in
+type SortMode string
+
+const (
+	SortNone      SortMode = "none"
+	SortHue       SortMode = "hue"
+	SortLightness SortMode = "lightness"
+	SortLuminance SortMode = "luminance"
+)
+
+func ParseSortMode(s string) (SortMode, error) {
+	switch mode := SortMode(s); mode {
+	case SortNone, SortHue, SortLightness, SortLuminance:
+		return mode, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrSortMode, s)
+}
+
+// Sorts the colors in ascending order of the mode.  SortNone returns nil as there's nothing to do.
+func SortBy(mode SortMode) Filter {
+	var key func(c *color.RGBA) float64
+	switch mode {
+	case SortHue:
+		key = func(c *color.RGBA) float64 {
+			h, _, _ := colorspace.ToHSL(c)
+			return h
+		}
+	case SortLightness:
+		key = func(c *color.RGBA) float64 {
+			_, _, l := colorspace.ToHSL(c)
+			return l
+		}
+	case SortLuminance:
+		key = colorspace.Luminance
+	default:
+		return nil
+	}
+	return func(colors []*color.RGBA) []*color.RGBA {
+		slices.SortStableFunc(colors, func(a *color.RGBA, b *color.RGBA) int {
+			ka, kb := key(a), key(b)
+			switch {
+			case ka < kb:
+				return -1
+			case ka > kb:
+				return 1
+			}
+			return 0
+		})
+		return colors
+	}
+}
+
+// Drops colors identical to the one before them, which would leave a flat spot in the gradient
+func DropAdjacentDuplicates(colors []*color.RGBA) []*color.RGBA {
+	return slices.CompactFunc(colors, func(a *color.RGBA, b *color.RGBA) bool {
+		return *a == *b
+	})
+}
+
+// Drops colors closer than distance, as measured by colorspace.Distance, to the last
+// color kept, so the gradient doesn't sit on nearly the same color.  The last color is
+// carried over between palettes, so the filter mustn't be shared between queues.
+func MinDistance(distance float64) Filter {
+	var last *color.RGBA
+	return func(colors []*color.RGBA) []*color.RGBA {
+		return slices.DeleteFunc(colors, func(c *color.RGBA) bool {
+			if last != nil && colorspace.Distance(last, c) < distance {
+				return true
+			}
+			last = c
+			return false
+		})
+	}
+}
+
+var ErrHueRange = errors.New("invalid hue range")
+
+// Palettes in a row HueRange drops entirely before it bends their hues into range
+const DefaultHueRejects = 10
+
+// Parses a hue range as "from,to" in degrees, ie. "330,60" for warm colors
+func ParseHueRange(s string) ([2]float64, error) {
+	from, to, ok := strings.Cut(s, ",")
+	if !ok {
+		return [2]float64{}, fmt.Errorf("%w: %q isn't from,to", ErrHueRange, s)
+	}
+	var r [2]float64
+	for i, v := range []string{from, to} {
+		deg, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || deg < 0 || deg > 360 {
+			return [2]float64{}, fmt.Errorf("%w: %q isn't a hue from 0 to 360", ErrHueRange, v)
+		}
+		r[i] = deg
+	}
+	return r, nil
+}
+
+// Reports whether hue is in the range running clockwise from r[0] to r[1], which
+// wraps through 0 when r[0] is the larger
+func inHueRange(r [2]float64, hue float64) bool {
+	if r[0] <= r[1] {
+		return hue >= r[0] && hue <= r[1]
+	}
+	return hue >= r[0] || hue <= r[1]
+}
+
+// Turns hue to the nearer end of the range when it's outside it
+func clampHue(r [2]float64, hue float64) float64 {
+	if inHueRange(r, hue) {
+		return hue
+	}
+	// distances around the circle to each end
+	dist := func(a, b float64) float64 {
+		d := math.Mod(math.Abs(a-b), 360)
+		return min(d, 360-d)
+	}
+	if dist(hue, r[0]) <= dist(hue, r[1]) {
+		return r[0]
+	}
+	return r[1]
+}
+
+// Drops colors whose hue is outside r, ie. keeping only warm or cool colors.  Greys
+// have no hue so are kept.  The queue fetches palettes until enough colors pass, so
+// once maxRejects palettes in a row have had none pass, their colors are turned to
+// the nearest hue in range instead of dropped rather than fetching forever.
+func HueRange(r [2]float64, maxRejects int) Filter {
+	rejects := 0
+	return func(colors []*color.RGBA) []*color.RGBA {
+		if rejects >= maxRejects {
+			rejects = 0
+			for i, c := range colors {
+				h, s, l := colorspace.ToHSL(c)
+				colors[i] = colorspace.FromHSL(clampHue(r, h), s, l, c.A)
+			}
+			return colors
+		}
+		colors = slices.DeleteFunc(colors, func(c *color.RGBA) bool {
+			h, s, _ := colorspace.ToHSL(c)
+			return s > 0 && !inHueRange(r, h)
+		})
+		if len(colors) == 0 {
+			rejects++
+		} else {
+			rejects = 0
+		}
+		return colors
+	}
+}
+
+// Moves the lightness, as in HSL, of every color into [low, high] keeping its hue and
+// saturation, so a dark palette doesn't disappear behind overlays
+func ClampLightness(low float64, high float64) Filter {
+	return func(colors []*color.RGBA) []*color.RGBA {
+		for i, c := range colors {
+			h, s, l := colorspace.ToHSL(c)
+			if l < low || l > high {
+				colors[i] = colorspace.FromHSL(h, s, min(max(l, low), high), c.A)
+			}
+		}
+		return colors
+	}
+}
+
+// Gives every color the same alpha, ie. to composite the stream over other video.  The
+// colors colormind returns are always opaque.
+func SetAlpha(alpha uint8) Filter {
+	return func(colors []*color.RGBA) []*color.RGBA {
+		for i, c := range colors {
+			if c.A != alpha {
+				colors[i] = &color.RGBA{R: c.R, G: c.G, B: c.B, A: alpha}
+			}
+		}
+		return colors
+	}
+}
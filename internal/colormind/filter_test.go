@@ -0,0 +1,249 @@
+package colormind
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image/color"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/broganross/color-run/internal/colorspace"
+)
+
+func TestSortBy(t *testing.T) {
+	colors := func() []*color.RGBA {
+		return []*color.RGBA{
+			{R: 200, G: 200, B: 200, A: 255},
+			{R: 10, G: 10, B: 80, A: 255},
+			{R: 255, G: 0, B: 0, A: 255},
+			{R: 0, G: 0, B: 0, A: 255},
+			{R: 40, G: 180, B: 40, A: 255},
+		}
+	}
+	keys := map[SortMode]func(c *color.RGBA) float64{
+		SortHue: func(c *color.RGBA) float64 {
+			h, _, _ := colorspace.ToHSL(c)
+			return h
+		},
+		SortLightness: func(c *color.RGBA) float64 {
+			_, _, l := colorspace.ToHSL(c)
+			return l
+		},
+		SortLuminance: colorspace.Luminance,
+	}
+	for mode, key := range keys {
+		got := SortBy(mode)(colors())
+		for i := 1; i < len(got); i++ {
+			if key(got[i]) < key(got[i-1]) {
+				t.Errorf("%s: colors %d and %d are out of order: %v", mode, i-1, i, got)
+			}
+		}
+	}
+	if SortBy(SortNone) != nil {
+		t.Error("SortNone should not return a filter")
+	}
+	if _, err := ParseSortMode("brightness"); !errors.Is(err, ErrSortMode) {
+		t.Errorf("got %v, want %v", err, ErrSortMode)
+	}
+}
+
+func TestPaletteQueueSortsLightness(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pal := [5][3]uint8{{200, 200, 200}, {30, 30, 30}, {250, 120, 120}, {90, 10, 10}, {0, 0, 0}}
+		json.NewEncoder(w).Encode(map[string]any{"result": pal})
+	}))
+	defer srv.Close()
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	colors, _ := PaletteQueue(ctx, NewModel("default"), cm, QueueOptions{
+		Size:    2,
+		Filters: []Filter{SortBy(SortLightness)},
+	})
+	first := make([]*color.RGBA, 5)
+	for i := range first {
+		first[i] = <-colors
+	}
+	for i := 1; i < len(first); i++ {
+		_, _, prev := colorspace.ToHSL(first[i-1])
+		_, _, cur := colorspace.ToHSL(first[i])
+		if cur <= prev {
+			t.Errorf("color %d is not lighter than the one before it: %v", i, first)
+		}
+	}
+}
+
+func TestPaletteQueueDropsAdjacentDuplicates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pal := [5][3]uint8{{10, 20, 30}, {40, 50, 60}, {40, 50, 60}, {70, 80, 90}, {100, 110, 120}}
+		json.NewEncoder(w).Encode(map[string]any{"result": pal})
+	}))
+	defer srv.Close()
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	colors, _ := PaletteQueue(ctx, NewModel("default"), cm, QueueOptions{
+		Size:    2,
+		Filters: []Filter{DropAdjacentDuplicates},
+	})
+	got := make([]*color.RGBA, 4)
+	for i := range got {
+		got[i] = <-colors
+	}
+	want := []color.RGBA{{R: 10, G: 20, B: 30, A: 255}, {R: 40, G: 50, B: 60, A: 255}, {R: 70, G: 80, B: 90, A: 255}, {R: 100, G: 110, B: 120, A: 255}}
+	for i := range want {
+		if *got[i] != want[i] {
+			t.Errorf("color %d: got %v, want %v", i, *got[i], want[i])
+		}
+	}
+}
+
+func TestPaletteQueueMinDistance(t *testing.T) {
+	clustered := func(cols ...[3]uint8) *Palette {
+		pal := &Palette{}
+		for i, c := range cols {
+			pal[i] = &color.RGBA{R: c[0], G: c[1], B: c[2], A: 255}
+		}
+		return pal
+	}
+	client := &FakeClient{Palettes: []*Palette{
+		clustered([3]uint8{200, 30, 30}, [3]uint8{201, 31, 30}, [3]uint8{199, 30, 32}, [3]uint8{30, 30, 200}, [3]uint8{31, 32, 201}),
+		clustered([3]uint8{30, 31, 199}, [3]uint8{30, 200, 30}, [3]uint8{31, 201, 31}, [3]uint8{32, 199, 30}, [3]uint8{200, 30, 31}),
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	const distance = 0.1
+	colors, _ := PaletteQueue(ctx, NewModel("default"), client, QueueOptions{
+		Size:    2,
+		Filters: []Filter{MinDistance(distance)},
+	})
+	got := make([]*color.RGBA, 8)
+	for i := range got {
+		got[i] = <-colors
+	}
+	for i := 1; i < len(got); i++ {
+		if d := colorspace.Distance(got[i-1], got[i]); d < distance {
+			t.Errorf("colors %d and %d are %g apart: %v and %v", i-1, i, d, *got[i-1], *got[i])
+		}
+	}
+}
+
+func TestHueRange(t *testing.T) {
+	hsl := func(h float64) *color.RGBA { return colorspace.FromHSL(h, 0.8, 0.5, 255) }
+	grey := &color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	// warm, wrapping through 0
+	warm := [2]float64{330, 60}
+	got := HueRange(warm, 3)([]*color.RGBA{hsl(0), hsl(200), hsl(45), grey, hsl(120), hsl(340)})
+	want := []*color.RGBA{hsl(0), hsl(45), grey, hsl(340)}
+	if len(got) != len(want) {
+		t.Fatalf("kept %d colors, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if *got[i] != *want[i] {
+			t.Errorf("color %d is %v, want %v", i, *got[i], *want[i])
+		}
+	}
+
+	// after 3 palettes with nothing in range the next is bent into it
+	f := HueRange(warm, 3)
+	for i := 0; i < 3; i++ {
+		if got := f([]*color.RGBA{hsl(180), hsl(200)}); len(got) != 0 {
+			t.Fatalf("palette %d: kept %v", i, got)
+		}
+	}
+	got = f([]*color.RGBA{hsl(180), hsl(100)})
+	if len(got) != 2 {
+		t.Fatalf("kept %d colors of the capped palette, want 2", len(got))
+	}
+	for i, c := range got {
+		if h, _, _ := colorspace.ToHSL(c); !inHueRange(warm, math.Round(h)) {
+			t.Errorf("color %d has hue %g, outside the range", i, h)
+		}
+	}
+	// and it starts counting again
+	if got := f([]*color.RGBA{hsl(180)}); len(got) != 0 {
+		t.Errorf("kept %v after the capped palette", got)
+	}
+
+	if r, err := ParseHueRange("330, 60"); err != nil || r != warm {
+		t.Errorf("got %v, %v", r, err)
+	}
+	for _, s := range []string{"", "330", "a,60", "0,400"} {
+		if _, err := ParseHueRange(s); !errors.Is(err, ErrHueRange) {
+			t.Errorf("%q: got %v, want %v", s, err, ErrHueRange)
+		}
+	}
+}
+
+func TestPaletteQueueHueRangeDoesntHang(t *testing.T) {
+	blue := &Palette{}
+	for i := range blue {
+		blue[i] = colorspace.FromHSL(220+float64(i), 0.8, 0.5, 255)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	colors, _ := PaletteQueue(ctx, NewModel("default"), &FakeClient{Palettes: []*Palette{blue}}, QueueOptions{
+		Size:    2,
+		Filters: []Filter{HueRange([2]float64{0, 60}, 5)},
+	})
+	for i := 0; i < 3; i++ {
+		select {
+		case c := <-colors:
+			if h, _, _ := colorspace.ToHSL(c); !inHueRange([2]float64{0, 60}, math.Round(h)) {
+				t.Errorf("color %d has hue %g", i, h)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for colors in range")
+		}
+	}
+}
+
+func TestClampLightness(t *testing.T) {
+	dark := []*color.RGBA{
+		{R: 20, G: 5, B: 5, A: 255},
+		{R: 3, G: 3, B: 30, A: 255},
+		{A: 255},
+		// already in range, left as it is
+		{R: 200, G: 100, B: 50, A: 255},
+	}
+	in := make([]color.RGBA, len(dark))
+	for i, c := range dark {
+		in[i] = *c
+	}
+	got := ClampLightness(0.3, 0.8)(slices.Clone(dark))
+	for i, c := range got {
+		h, s, l := colorspace.ToHSL(c)
+		// a level's rounding either way
+		if l < 0.3-1.0/255 || l > 0.8+1.0/255 {
+			t.Errorf("color %d has lightness %g", i, l)
+		}
+		h0, s0, _ := colorspace.ToHSL(&in[i])
+		if math.Abs(h-h0) > 2 || math.Abs(s-s0) > 0.02 {
+			t.Errorf("color %d went from hue %g saturation %g to %g %g", i, h0, s0, h, s)
+		}
+	}
+	if *got[3] != in[3] {
+		t.Errorf("changed %v, which was in range, to %v", in[3], *got[3])
+	}
+}
+
+func TestSetAlpha(t *testing.T) {
+	opaque := &color.RGBA{R: 10, G: 20, B: 30, A: 255}
+	got := SetAlpha(64)([]*color.RGBA{opaque, {R: 1, A: 64}})
+	if want := (color.RGBA{R: 10, G: 20, B: 30, A: 64}); *got[0] != want {
+		t.Errorf("got %v, want %v", *got[0], want)
+	}
+	if opaque.A != 255 {
+		t.Error("changed the palette's color in place")
+	}
+	if got[1].A != 64 {
+		t.Errorf("got alpha %d", got[1].A)
+	}
+}
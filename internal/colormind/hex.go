@@ -0,0 +1,32 @@
+package colormind
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Formats the color as #rrggbb, alpha is dropped as colormind doesn't use it
+func (c *Color) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Parses a #rgb, #rrggbb or #rrggbbaa color, the # is optional.  Colors without an
+// alpha are opaque.
+func ParseHexColor(s string) (*Color, error) {
+	digits := strings.TrimPrefix(s, "#")
+	switch len(digits) {
+	case 3:
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]}) + "ff"
+	case 6:
+		digits += "ff"
+	case 8:
+	default:
+		return nil, fmt.Errorf("%w: color %q must be 3, 6 or 8 hex digits", ErrValidation, s)
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: color %q is not hex", ErrValidation, s)
+	}
+	return &Color{uint8(v >> 24), uint8(v >> 16), uint8(v >> 8), uint8(v)}, nil
+}
@@ -0,0 +1,98 @@
+package colormind
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Color
+	}{
+		{"#f80", Color{0xff, 0x88, 0x00, 0xff}},
+		{"#ff8800", Color{0xff, 0x88, 0x00, 0xff}},
+		{"FF8800", Color{0xff, 0x88, 0x00, 0xff}},
+		{"#ff880080", Color{0xff, 0x88, 0x00, 0x80}},
+	}
+	for _, tt := range tests {
+		got, err := ParseHexColor(tt.in)
+		if err != nil || *got != tt.want {
+			t.Errorf("ParseHexColor(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "#ff88", "#ff88001", "#gg8800", "#-f8800"} {
+		if _, err := ParseHexColor(in); !errors.Is(err, ErrValidation) {
+			t.Errorf("ParseHexColor(%q) returned %v, want %v", in, err, ErrValidation)
+		}
+	}
+}
+
+func TestColorHex(t *testing.T) {
+	c := &Color{0x0a, 0xbc, 0xff, 0x80}
+	if got := c.Hex(); got != "#0abcff" {
+		t.Errorf("got %s, want #0abcff", got)
+	}
+	parsed, err := ParseHexColor(c.Hex())
+	if err != nil || parsed.R != c.R || parsed.G != c.G || parsed.B != c.B {
+		t.Errorf("round trip gave %v, %v", parsed, err)
+	}
+}
+
+func TestColorJSON(t *testing.T) {
+	in := Color{R: 12, G: 200, B: 255, A: 255}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "[12,200,255]" {
+		t.Errorf("marshaled to %s", b)
+	}
+	// through a pointer and inside other values too
+	b, err = json.Marshal(map[string]*Color{"c": &in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]Color{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("unmarshaling %s: %s", b, err)
+	}
+	if out["c"] != in {
+		t.Errorf("round tripped %v to %v", in, out["c"])
+	}
+	var c Color
+	if err := json.Unmarshal([]byte(`"N"`), &c); !errors.Is(err, ErrEmptyColor) {
+		t.Errorf("got %v for an empty slot, want %v", err, ErrEmptyColor)
+	}
+	if err := json.Unmarshal([]byte(`"red"`), &c); err == nil || errors.Is(err, ErrEmptyColor) {
+		t.Errorf("got %v unmarshaling a string", err)
+	}
+}
+
+func TestPaletteJSONPlaceholders(t *testing.T) {
+	in := `[[44,43,44],"N",[90,83,82],"N","N"]`
+	p := Palette{}
+	if err := json.Unmarshal([]byte(in), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p[0] == nil || *p[0] != (color.RGBA{R: 44, G: 43, B: 44, A: 255}) || p[2] == nil || p[2].R != 90 {
+		t.Errorf("got %v", p)
+	}
+	for _, i := range []int{1, 3, 4} {
+		if p[i] != nil {
+			t.Errorf("slot %d is %v, want nil", i, *p[i])
+		}
+	}
+	out, err := json.Marshal(&p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != in {
+		t.Errorf("round tripped to %s, want %s", out, in)
+	}
+	if err := json.Unmarshal([]byte(`[[1,2,3],"X","N","N","N"]`), &p); err == nil {
+		t.Error("unmarshaled a placeholder other than N")
+	}
+}
@@ -0,0 +1,49 @@
+package colormind
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+)
+
+// Returns a copy of the palette keeping only the colors at the indexes, so a request
+// seeded with it keeps those positions and regenerates the rest
+func (p *Palette) Lock(indexes ...int) (*Palette, error) {
+	fixed := map[int]*color.RGBA{}
+	for _, i := range indexes {
+		if i < 0 || i >= len(p) {
+			return nil, fmt.Errorf("%w: palette index %d out of range", ErrValidation, i)
+		}
+		fixed[i] = p[i]
+	}
+	return lockedPalette(fixed)
+}
+
+// Builds the input of a request which keeps the fixed colors at their positions
+func lockedPalette(fixed map[int]*color.RGBA) (*Palette, error) {
+	p := &Palette{}
+	locked := 0
+	for i, c := range fixed {
+		if i < 0 || i >= len(p) {
+			return nil, fmt.Errorf("%w: palette index %d out of range", ErrValidation, i)
+		}
+		if c != nil {
+			p[i] = c
+			locked++
+		}
+	}
+	if locked == 0 {
+		return nil, ErrEmptyPalette
+	}
+	return p, nil
+}
+
+// Requests a palette from the model with the fixed colors kept at their positions,
+// ie. {0: a, 4: b} regenerates positions 1 to 3.  At least one color must be fixed.
+func (c *ColorMind) GetPaletteLocked(ctx context.Context, model string, fixed map[int]*color.RGBA) (*Palette, error) {
+	p, err := lockedPalette(fixed)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetPaletteWithContext(ctx, model, p)
+}
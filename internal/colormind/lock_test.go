@@ -0,0 +1,53 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"testing"
+)
+
+func TestPaletteLock(t *testing.T) {
+	pal := &Palette{}
+	for i := range pal {
+		pal[i] = &color.RGBA{uint8(i), 0, 0, 255}
+	}
+	locked, err := pal.Lock(0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range locked {
+		if keep := i == 0 || i == 4; (c != nil) != keep || (keep && c != pal[i]) {
+			t.Errorf("position %d is %v", i, c)
+		}
+	}
+	if _, err := pal.Lock(); !errors.Is(err, ErrEmptyPalette) {
+		t.Errorf("locking nothing returned %v, want %v", err, ErrEmptyPalette)
+	}
+	if _, err := pal.Lock(5); !errors.Is(err, ErrValidation) {
+		t.Errorf("locking position 5 returned %v, want %v", err, ErrValidation)
+	}
+}
+
+func TestGetPaletteLocked(t *testing.T) {
+	srv, rec := newPaletteServer(t)
+	cm := New()
+	cm.URL = srv.URL
+	fixed := map[int]*color.RGBA{1: {R: 10, G: 20, B: 30, A: 255}, 3: {R: 40, G: 50, B: 60, A: 255}}
+	if _, err := cm.GetPaletteLocked(context.Background(), "default", fixed); err != nil {
+		t.Fatal(err)
+	}
+	reqs, _ := rec.Requests()
+	want := []string{`"N"`, "[10,20,30]", `"N"`, "[40,50,60]", `"N"`}
+	if len(reqs) != 1 || len(reqs[0].Input) != len(want) {
+		t.Fatalf("got requests %v", reqs)
+	}
+	for i, in := range reqs[0].Input {
+		if string(in) != want[i] {
+			t.Errorf("input %d is %s, want %s", i, in, want[i])
+		}
+	}
+	if _, err := cm.GetPaletteLocked(context.Background(), "default", map[int]*color.RGBA{2: nil}); !errors.Is(err, ErrEmptyPalette) {
+		t.Errorf("nothing locked returned %v, want %v", err, ErrEmptyPalette)
+	}
+}
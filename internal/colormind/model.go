@@ -0,0 +1,92 @@
+package colormind
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Holds the name of the model used to generate palettes.  It is safe to
+// change while a PaletteQueue is reading it.
+type Model struct {
+	mu   sync.RWMutex
+	name string
+}
+
+func NewModel(name string) *Model {
+	return &Model{name: name}
+}
+
+func (m *Model) Get() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.name
+}
+
+func (m *Model) Set(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.name = name
+}
+
+// Restricts the available models to the allowed ones.  Surrounding whitespace is
+// ignored and every allowed model must be available.  If nothing is allowed all the
+// available models are returned.
+func FilterModels(available []string, allowed []string) ([]string, error) {
+	out := make([]string, 0, len(allowed))
+	for _, name := range allowed {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(out, name) {
+			continue
+		}
+		if !slices.Contains(available, name) {
+			return nil, fmt.Errorf("%w: unknown model %q", ErrValidation, name)
+		}
+		out = append(out, name)
+	}
+	if len(out) == 0 {
+		return available, nil
+	}
+	return out, nil
+}
+
+// Picks a model from models at random with rng, other than current unless it's the
+// only one.  The same seed picks the same models.
+func PickModel(rng *rand.Rand, models []string, current string) string {
+	candidates := models
+	if len(models) > 1 {
+		candidates = slices.DeleteFunc(slices.Clone(models), func(name string) bool {
+			return name == current
+		})
+	}
+	return candidates[rng.Intn(len(candidates))]
+}
+
+// Picks a new random model, other than the current one, from models every interval until the context is done.
+// Since the palette queue seeds each request with the tail of the previous
+// palette, the first palette from the new model starts on the colors currently
+// being shown and the stream crossfades into the new model rather than jumping.
+// rng must not be used elsewhere while it runs.
+func RotateModel(ctx context.Context, m *Model, models []string, interval time.Duration, rng *rand.Rand) {
+	if len(models) == 0 || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			name := PickModel(rng, models, m.Get())
+			log.Info().Str("model", name).Msg("rotating color model")
+			m.Set(name)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRotateModel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewModel("a")
+	interval := 50 * time.Millisecond
+	go RotateModel(ctx, m, []string{"a", "b", "c"}, interval, rand.New(rand.NewSource(1)))
+
+	time.Sleep(interval / 2)
+	if got := m.Get(); got != "a" {
+		t.Fatalf("model changed to %q before the interval", got)
+	}
+	previous := "a"
+	for i := 0; i < 3; i++ {
+		deadline := time.Now().Add(4 * interval)
+		for m.Get() == previous && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		got := m.Get()
+		if got == previous {
+			t.Fatalf("model did not change from %q within the interval", previous)
+		}
+		previous = got
+	}
+}
+
+func TestRotateModelCrossfades(t *testing.T) {
+	srv, rec := newPaletteServer(t)
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := NewModel("a")
+	colors, _ := PaletteQueue(ctx, m, cm, QueueOptions{Size: 2})
+	for i := 0; i < 5; i++ {
+		<-colors
+	}
+	m.Set("b")
+	var first int
+	for first == 0 {
+		<-colors
+		reqs, _ := rec.Requests()
+		for i, req := range reqs {
+			if req.Model == "b" {
+				first = i
+				break
+			}
+		}
+	}
+	reqs, resps := rec.Requests()
+	input := reqs[first].Input
+	previous := resps[first-1]
+	if len(input) != 5 {
+		t.Fatalf("first request for the new model has %d input colors, want 5", len(input))
+	}
+	if string(input[0]) != colorJSON(previous[3]) || string(input[1]) != colorJSON(previous[4]) {
+		t.Errorf("new model was seeded with %s, %s; want the tail of the previous palette %v, %v",
+			input[0], input[1], previous[3], previous[4])
+	}
+}
+
+func TestFilterModels(t *testing.T) {
+	available := []string{"default", "ui", "makoto_shinkai", "metroid_fusion"}
+	tests := []struct {
+		name    string
+		allowed []string
+		want    []string
+		err     error
+	}{
+		{"nothing allowed", nil, available, nil},
+		{"subset", []string{"ui", "default"}, []string{"ui", "default"}, nil},
+		{"whitespace", []string{" ui", "default ", ""}, []string{"ui", "default"}, nil},
+		{"duplicates", []string{"ui", "ui"}, []string{"ui"}, nil},
+		{"unknown", []string{"ui", "nope"}, nil, ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterModels(available, tt.allowed)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotateModelStaysInList(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	allowed := []string{"ui", "default"}
+	m := NewModel("ui")
+	go RotateModel(ctx, m, allowed, time.Millisecond, rand.New(rand.NewSource(1)))
+	for i := 0; i < 200; i++ {
+		if got := m.Get(); !slices.Contains(allowed, got) {
+			t.Fatalf("rotated to %q which is not in %v", got, allowed)
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+func TestPickModelSeeded(t *testing.T) {
+	models := []string{"default", "ui", "makoto_shinkai", "metroid_fusion", "flower_photography"}
+	pick := func(seed int64) []string {
+		rng := rand.New(rand.NewSource(seed))
+		picked := []string{PickModel(rng, models, "")}
+		for i := 0; i < 10; i++ {
+			picked = append(picked, PickModel(rng, models, picked[len(picked)-1]))
+		}
+		return picked
+	}
+	first, second := pick(42), pick(42)
+	if !slices.Equal(first, second) {
+		t.Errorf("the same seed picked %v then %v", first, second)
+	}
+	for i := 1; i < len(first); i++ {
+		if first[i] == first[i-1] {
+			t.Errorf("pick %d repeated %q", i, first[i])
+		}
+	}
+	if got := PickModel(rand.New(rand.NewSource(1)), []string{"ui"}, "ui"); got != "ui" {
+		t.Errorf("got %q from the only model", got)
+	}
+}
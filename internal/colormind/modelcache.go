@@ -0,0 +1,64 @@
+package colormind
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Models from the last successful list request, and the request in flight if any
+type modelCache struct {
+	mu      sync.Mutex
+	models  []string
+	fetched time.Time
+	call    *modelCall
+}
+
+// A list request other callers can wait on
+type modelCall struct {
+	done   chan struct{}
+	models []string
+	err    error
+}
+
+// Returns the cached models if they're younger than ttl, otherwise fetches them.
+// Callers arriving while a fetch is in flight wait for its result rather than making
+// their own, so they also share its failure.
+func (mc *modelCache) get(ctx context.Context, ttl time.Duration, fetch func(context.Context) ([]string, error)) ([]string, error) {
+	mc.mu.Lock()
+	if mc.models != nil && time.Since(mc.fetched) < ttl {
+		models := slices.Clone(mc.models)
+		mc.mu.Unlock()
+		return models, nil
+	}
+	if call := mc.call; call != nil {
+		mc.mu.Unlock()
+		select {
+		case <-call.done:
+			return slices.Clone(call.models), call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &modelCall{done: make(chan struct{})}
+	mc.call = call
+	mc.mu.Unlock()
+
+	call.models, call.err = fetch(ctx)
+	mc.mu.Lock()
+	if call.err == nil {
+		mc.models = call.models
+		mc.fetched = time.Now()
+	}
+	mc.call = nil
+	mc.mu.Unlock()
+	close(call.done)
+	return slices.Clone(call.models), call.err
+}
+
+func (mc *modelCache) clear() {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.models = nil
+}
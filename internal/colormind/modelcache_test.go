@@ -0,0 +1,57 @@
+package colormind
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestListModelsCache(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// long enough for every caller to arrive while the first is in flight
+		time.Sleep(50 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]any{"result": []string{"default", "ui"}})
+	}))
+	defer srv.Close()
+	cm := New()
+	cm.URL = srv.URL
+	cm.MaxConcurrent = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if models, err := cm.ListModelsWithContext(context.Background()); err != nil || len(models) != 2 {
+				t.Errorf("got %v, %v", models, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("concurrent callers made %d requests, want 1", got)
+	}
+	models, _ := cm.ListModels()
+	models[0] = "changed"
+	if models, _ := cm.ListModels(); models[0] != "default" || requests.Load() != 1 {
+		t.Errorf("got %v after %d requests, want the cached models untouched by callers", models, requests.Load())
+	}
+	cm.ClearModelCache()
+	cm.ListModels()
+	if got := requests.Load(); got != 2 {
+		t.Errorf("got %d requests after clearing the cache, want 2", got)
+	}
+	cm.ModelCacheTTL = time.Millisecond
+	time.Sleep(2 * time.Millisecond)
+	cm.ListModels()
+	if got := requests.Load(); got != 3 {
+		t.Errorf("got %d requests once the cache expired, want 3", got)
+	}
+}
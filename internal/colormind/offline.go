@@ -0,0 +1,143 @@
+package colormind
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+
+	"github.com/broganross/color-run/internal/colorspace"
+	"github.com/rs/zerolog/log"
+)
+
+// Degrees the offline hue moves between colors.  Stepping by the golden ratio of the
+// circle never lands on the same hue twice and keeps neighbouring colors apart.
+var goldenAngle = 360 * (math.Phi - 1)
+
+// PaletteClient which makes palettes up without the network, walking around the hue
+// circle by the golden angle.  The same seed gives the same palettes.
+type OfflineClient struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+	hue float64
+}
+
+func NewOfflineClient(seed int64) *OfflineClient {
+	rng := rand.New(rand.NewSource(seed))
+	return &OfflineClient{rng: rng, hue: rng.Float64() * 360}
+}
+
+// Fills the empty slots of p, or every slot when it's nil, carrying on around the hue
+// circle.  Model is ignored.
+func (oc *OfflineClient) GetPaletteWithContext(ctx context.Context, model string, p *Palette) (*Palette, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+	pal := &Palette{}
+	if p != nil {
+		*pal = *p
+	}
+	for i := range pal {
+		if pal[i] != nil {
+			continue
+		}
+		oc.hue = math.Mod(oc.hue+goldenAngle, 360)
+		// saturated and mid lightness so the colors aren't washed out or muddy
+		pal[i] = colorspace.FromHSL(oc.hue, 0.55+oc.rng.Float64()*0.35, 0.4+oc.rng.Float64()*0.25, 255)
+	}
+	return pal, nil
+}
+
+func (oc *OfflineClient) ListModelsWithContext(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(BundledModels), nil
+}
+
+const DefaultFallbackProbe = 10
+
+// PaletteClient which switches from Primary to Fallback once Primary has failed After
+// times in a row, and back once Primary answers again.  While it's falling back every
+// Probe-th palette is asked of Primary first to see whether it has recovered.
+type FallbackClient struct {
+	Primary  PaletteClient
+	Fallback PaletteClient
+	After    int
+	// Defaults to DefaultFallbackProbe
+	Probe    int
+	mu       sync.Mutex
+	failures int
+	// palettes served by Fallback since switching to it
+	served int
+}
+
+// Whether Primary has failed enough to be falling back
+func (fc *FallbackClient) FallingBack() bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.fallingBack()
+}
+
+func (fc *FallbackClient) fallingBack() bool {
+	return fc.failures >= max(fc.After, 1)
+}
+
+func (fc *FallbackClient) usePrimary() bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	probe := fc.Probe
+	if probe <= 0 {
+		probe = DefaultFallbackProbe
+	}
+	return !fc.fallingBack() || fc.served%probe == 0
+}
+
+// Counts Primary's consecutive failures, reporting whether it's falling back
+func (fc *FallbackClient) record(err error) bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	was := fc.fallingBack()
+	if err == nil {
+		fc.failures = 0
+		fc.served = 0
+		if was {
+			log.Info().Msg("palette source recovered, switching back to it")
+		}
+		return false
+	}
+	fc.failures++
+	if !was && fc.fallingBack() {
+		log.Warn().Err(err).Int("failures", fc.failures).Msg("palette source keeps failing, falling back")
+	}
+	return fc.fallingBack()
+}
+
+func (fc *FallbackClient) GetPaletteWithContext(ctx context.Context, model string, p *Palette) (*Palette, error) {
+	if fc.usePrimary() {
+		pal, err := fc.Primary.GetPaletteWithContext(ctx, model, p)
+		// a cancelled request says nothing about Primary
+		if ctx.Err() != nil {
+			return pal, err
+		}
+		if !fc.record(err) {
+			return pal, err
+		}
+	}
+	fc.mu.Lock()
+	fc.served++
+	fc.mu.Unlock()
+	return fc.Fallback.GetPaletteWithContext(ctx, model, p)
+}
+
+// Lists Primary's models, or Fallback's when Primary can't
+func (fc *FallbackClient) ListModelsWithContext(ctx context.Context) ([]string, error) {
+	models, err := fc.Primary.ListModelsWithContext(ctx)
+	if err != nil && ctx.Err() == nil {
+		return fc.Fallback.ListModelsWithContext(ctx)
+	}
+	return models, err
+}
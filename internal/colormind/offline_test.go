@@ -0,0 +1,89 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"testing"
+)
+
+func TestOfflineClient(t *testing.T) {
+	ctx := context.Background()
+	a, b := NewOfflineClient(7), NewOfflineClient(7)
+	for i := 0; i < 20; i++ {
+		pa, err := a.GetPaletteWithContext(ctx, "default", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pb, _ := b.GetPaletteWithContext(ctx, "default", nil)
+		for j, c := range pa {
+			if c == nil {
+				t.Fatalf("palette %d color %d is nil", i, j)
+			}
+			if c.A != 255 {
+				t.Errorf("palette %d color %d isn't opaque: %v", i, j, *c)
+			}
+			if *c != *pb[j] {
+				t.Errorf("palette %d color %d differs between clients with the same seed", i, j)
+			}
+		}
+	}
+	// locked colors are kept
+	locked := &color.RGBA{R: 1, G: 2, B: 3, A: 255}
+	pal, err := a.GetPaletteWithContext(ctx, "default", &Palette{locked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pal[0] != locked || pal[1] == nil {
+		t.Errorf("got %v with %v locked", pal, *locked)
+	}
+}
+
+func TestFallbackClient(t *testing.T) {
+	down := errors.New("colormind is down")
+	primaryPal := &Palette{&color.RGBA{R: 1, A: 255}, &color.RGBA{R: 2, A: 255}, &color.RGBA{R: 3, A: 255}, &color.RGBA{R: 4, A: 255}, &color.RGBA{R: 5, A: 255}}
+	fallbackPal := &Palette{&color.RGBA{B: 1, A: 255}, &color.RGBA{B: 2, A: 255}, &color.RGBA{B: 3, A: 255}, &color.RGBA{B: 4, A: 255}, &color.RGBA{B: 5, A: 255}}
+	// fails 4 requests then recovers
+	primary := &FakeClient{Palettes: []*Palette{primaryPal}, Errors: []error{down, down, down, down}}
+	fc := &FallbackClient{
+		Primary:  primary,
+		Fallback: &FakeClient{Palettes: []*Palette{fallbackPal}},
+		After:    3,
+		Probe:    2,
+	}
+	ctx := context.Background()
+	tests := []struct {
+		from        *Palette
+		err         bool
+		fallingBack bool
+	}{
+		// the first two failures are passed on
+		{nil, true, false},
+		{nil, true, false},
+		// the third falls back
+		{fallbackPal, false, true},
+		{fallbackPal, false, true},
+		// probes the primary, which fails again
+		{fallbackPal, false, true},
+		{fallbackPal, false, true},
+		// probes it again, and it's recovered
+		{primaryPal, false, false},
+		{primaryPal, false, false},
+	}
+	for i, tt := range tests {
+		pal, err := fc.GetPaletteWithContext(ctx, "default", nil)
+		if (err != nil) != tt.err {
+			t.Fatalf("request %d: got error %v", i, err)
+		}
+		if tt.from != nil && (pal == nil || *pal[0] != *tt.from[0]) {
+			t.Errorf("request %d: got %v, want a palette from %v", i, pal, tt.from)
+		}
+		if fc.FallingBack() != tt.fallingBack {
+			t.Errorf("request %d: falling back %t, want %t", i, fc.FallingBack(), tt.fallingBack)
+		}
+	}
+	// skipped while falling back between probes
+	if got := len(primary.Requests()); got != 6 {
+		t.Errorf("primary got %d requests, want 6", got)
+	}
+}
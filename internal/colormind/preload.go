@@ -0,0 +1,35 @@
+package colormind
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrNoPalettes = errors.New("no palettes")
+
+// Reads a JSON array of palettes, each an array of five [r, g, b] colors like the
+// API returns
+func LoadPalettes(path string) ([]*Palette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading palettes: %w", err)
+	}
+	palettes := []*Palette{}
+	if err := json.Unmarshal(b, &palettes); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseBody, err)
+	}
+	if len(palettes) == 0 {
+		return nil, fmt.Errorf("%w in %s", ErrNoPalettes, path)
+	}
+	for i, pal := range palettes {
+		if pal == nil {
+			return nil, fmt.Errorf("%w: palette %d is null", ErrParseBody, i)
+		}
+		if err := pal.filled(); err != nil {
+			return nil, fmt.Errorf("%w: palette %d: %w", ErrParseBody, i, err)
+		}
+	}
+	return palettes, nil
+}
@@ -0,0 +1,59 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPaletteQueuePreload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "palettes.json")
+	contents := `[
+		[[1,1,1],[2,2,2],[3,3,3],[4,4,4],[5,5,5]],
+		[[6,6,6],[7,7,7],[8,8,8],[9,9,9],[10,10,10]]
+	]`
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	palettes, err := LoadPalettes(path)
+	if err != nil {
+		t.Fatalf("loading palettes: %s", err)
+	}
+	// nothing is listening here
+	srv, _ := newPaletteServer(t)
+	srv.Close()
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	colors, _ := PaletteQueue(ctx, NewModel("default"), cm, QueueOptions{Size: 2, Preload: palettes})
+	for i := 1; i <= 10; i++ {
+		got := <-colors
+		if want := (color.RGBA{uint8(i), uint8(i), uint8(i), 255}); *got != want {
+			t.Errorf("color %d is %v, want %v", i, *got, want)
+		}
+	}
+}
+
+func TestLoadPalettesEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "palettes.json")
+	if err := os.WriteFile(path, []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPalettes(path); !errors.Is(err, ErrNoPalettes) {
+		t.Errorf("got %v, want %v", err, ErrNoPalettes)
+	}
+}
+
+func TestLoadPalettesEmptySlot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "palettes.json")
+	if err := os.WriteFile(path, []byte(`[[[1,1,1],"N",[3,3,3],[4,4,4],[5,5,5]]]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPalettes(path); !errors.Is(err, ErrParseBody) || !errors.Is(err, ErrEmptyColor) {
+		t.Errorf("got %v, want %v for the empty slot", err, ErrParseBody)
+	}
+}
@@ -0,0 +1,67 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// How failed palette requests are retried.  Attempts back off exponentially from
+// BaseDelay up to MaxDelay, with jitter so concurrent callers don't retry in step.
+type RetryConfig struct {
+	// Total number of attempts, 0 or 1 doesn't retry
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Waits between three attempts for up to a few seconds
+var DefaultRetry = RetryConfig{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// Wait before the attempt following the given one, the first being 1.  Half of it is
+// fixed and half random.
+func (rc RetryConfig) delay(attempt int) time.Duration {
+	d := rc.BaseDelay
+	for i := 1; i < attempt && (rc.MaxDelay <= 0 || d < rc.MaxDelay); i++ {
+		d *= 2
+	}
+	if rc.MaxDelay > 0 && d > rc.MaxDelay {
+		d = rc.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Response with a status other than 200 OK.  It matches ErrResponseStatus.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (se *StatusError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", ErrResponseStatus, http.StatusText(se.Code), se.Body)
+}
+
+func (se *StatusError) Is(target error) bool {
+	return target == ErrResponseStatus
+}
+
+// Whether the failure may be transient: the network, a timeout, or the server being
+// unwell.  Requests the server rejected will be rejected again.
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code >= 500 || se.Code == http.StatusTooManyRequests
+	}
+	return errors.Is(err, ErrPost) || errors.Is(err, ErrReadBody) || errors.Is(err, ErrEmptyBody) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
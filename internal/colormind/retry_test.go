@@ -0,0 +1,91 @@
+package colormind
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Starts a server failing the first fails requests with status, then answering with a palette
+func newFlakyServer(t *testing.T, fails int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= fails {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"result":[[1,2,3],[4,5,6],[7,8,9],[10,11,12],[13,14,15]]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestGetPaletteRetry(t *testing.T) {
+	retry := RetryConfig{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 4 * time.Millisecond}
+	tests := []struct {
+		name     string
+		fails    int32
+		status   int
+		wantErr  error
+		requests int32
+	}{
+		{"recovers", 3, http.StatusServiceUnavailable, nil, 4},
+		{"gives up", 10, http.StatusInternalServerError, ErrResponseStatus, 4},
+		{"rate limited", 1, http.StatusTooManyRequests, nil, 2},
+		{"rejected", 10, http.StatusBadRequest, ErrResponseStatus, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := newFlakyServer(t, tt.fails, tt.status)
+			cm := New()
+			cm.URL = srv.URL
+			cm.Retry = retry
+			pal, err := cm.GetPalette("default", nil)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && pal[4].B != 15 {
+				t.Errorf("got palette %v", pal)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("got %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestGetPaletteRetryCancel(t *testing.T) {
+	srv, requests := newFlakyServer(t, 100, http.StatusBadGateway)
+	cm := New()
+	cm.URL = srv.URL
+	cm.Retry = RetryConfig{MaxAttempts: 100, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := cm.GetPaletteWithContext(ctx, "default", nil)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrResponseStatus) {
+		t.Errorf("got %v, want the deadline and the last failure", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("took %v to give up after the context ended", d)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	rc := RetryConfig{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 10: 300 * time.Millisecond} {
+		for i := 0; i < 20; i++ {
+			if d := rc.delay(attempt); d < want/2 || d > want {
+				t.Fatalf("attempt %d waited %v, want between %v and %v", attempt, d, want/2, want)
+			}
+		}
+	}
+}
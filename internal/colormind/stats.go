@@ -0,0 +1,20 @@
+package colormind
+
+import "sync/atomic"
+
+// Counts the palettes PaletteQueue fetches, safe to read while it runs.  Preloaded
+// palettes aren't fetched so aren't counted.
+type QueueStats struct {
+	palettes atomic.Int64
+	errors   atomic.Int64
+}
+
+// Number of palettes fetched successfully
+func (qs *QueueStats) PalettesFetched() int64 {
+	return qs.palettes.Load()
+}
+
+// Number of palette requests which failed, after any retries
+func (qs *QueueStats) PaletteErrors() int64 {
+	return qs.errors.Load()
+}
@@ -0,0 +1,56 @@
+package colormind
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+type StatusResponse struct {
+	Model   string   `json:"model"`
+	Palette []string `json:"palette"`
+}
+
+// Keeps the most recently fetched palette and the model it came from
+type Status struct {
+	mu      sync.Mutex
+	model   string
+	palette []string
+}
+
+// Records the palette, suitable as QueueOptions.OnPalette
+func (s *Status) Set(model string, pal *Palette) {
+	hex := make([]string, 0, len(pal))
+	for _, c := range pal {
+		if c != nil {
+			hex = append(hex, (*Color)(c).Hex())
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.model = model
+	s.palette = hex
+}
+
+func (s *Status) Get() StatusResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return StatusResponse{
+		Model:   s.model,
+		Palette: append([]string{}, s.palette...),
+	}
+}
+
+// Serves the current palette as JSON
+func (s *Status) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Get()); err != nil {
+		log.Error().Err(err).Msg("writing palette response")
+	}
+}
@@ -0,0 +1,50 @@
+package colormind
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	srv, _ := newPaletteServer(t)
+	cm := New()
+	cm.URL = srv.URL
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	status := &Status{}
+	colors, _ := PaletteQueue(ctx, NewModel("ui"), cm, QueueOptions{Size: 2, OnPalette: status.Set})
+	for i := 0; i < 5; i++ {
+		<-colors
+	}
+	rec := httptest.NewRecorder()
+	status.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/palette", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d", rec.Code)
+	}
+	got := StatusResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding response: %s", err)
+	}
+	if got.Model != "ui" {
+		t.Errorf("got model %q, want ui", got.Model)
+	}
+	if len(got.Palette) != 5 {
+		t.Fatalf("got %d colors, want 5", len(got.Palette))
+	}
+	hex := regexp.MustCompile(`^#[0-9a-f]{6}$`)
+	for _, c := range got.Palette {
+		if !hex.MatchString(c) {
+			t.Errorf("%q isn't a hex color", c)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	status.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/palette", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST got status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,22 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+)
+
+// Perceptual distance between two colors, the straight line between them in OKLab.
+// Black to white is 1 and colors under about 0.02 apart look the same.
+func Distance(c1 *color.RGBA, c2 *color.RGBA) float64 {
+	L1, a1, b1 := toOKLab(c1)
+	L2, a2, b2 := toOKLab(c2)
+	return math.Sqrt((L1-L2)*(L1-L2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+}
+
+func toOKLab(c *color.RGBA) (float64, float64, float64) {
+	return LinearToOKLab(
+		SRGBToLinear(float64(c.R)/255),
+		SRGBToLinear(float64(c.G)/255),
+		SRGBToLinear(float64(c.B)/255),
+	)
+}
@@ -0,0 +1,26 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	black := &color.RGBA{A: 255}
+	white := &color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	if got := Distance(black, white); math.Abs(got-1) > 1e-6 {
+		t.Errorf("black to white is %g, want 1", got)
+	}
+	if got := Distance(white, white); got != 0 {
+		t.Errorf("white to itself is %g", got)
+	}
+	// a step of one level is imperceptible, red to green isn't
+	red := &color.RGBA{R: 255, A: 255}
+	if got := Distance(red, &color.RGBA{R: 254, A: 255}); got > 0.02 {
+		t.Errorf("one level apart is %g", got)
+	}
+	if got := Distance(red, &color.RGBA{G: 255, A: 255}); got < 0.2 {
+		t.Errorf("red to green is %g", got)
+	}
+}
@@ -0,0 +1,79 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+)
+
+// Converts to hue in degrees [0, 360), and saturation and lightness in [0, 1]
+func ToHSL(c *color.RGBA) (float64, float64, float64) {
+	r := float64(c.R) / 255
+	g := float64(c.G) / 255
+	b := float64(c.B) / 255
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	l := (maxC + minC) / 2
+	d := maxC - minC
+	if d == 0 {
+		return 0, 0, l
+	}
+	s := d / (1 - math.Abs(2*l-1))
+	var h float64
+	switch maxC {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// Converts hue in degrees, and saturation and lightness in [0, 1] to a color with the given alpha
+func FromHSL(h float64, s float64, l float64, a uint8) *color.RGBA {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return &color.RGBA{to8(r + m), to8(g + m), to8(b + m), a}
+}
+
+// Relative luminance, in [0, 1], using the Rec. 709 weights on the sRGB values
+func Luminance(c *color.RGBA) float64 {
+	return (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 255
+}
+
+// Rounds a [0, 1] value to 8 bits, clamping out of range values
+func to8(v float64) uint8 {
+	v = math.Round(v * 255)
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return uint8(v)
+}
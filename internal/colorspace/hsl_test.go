@@ -0,0 +1,39 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestHSLRoundTrip(t *testing.T) {
+	tests := []struct {
+		c       color.RGBA
+		h, s, l float64
+	}{
+		{color.RGBA{255, 0, 0, 255}, 0, 1, 0.5},
+		{color.RGBA{0, 255, 0, 255}, 120, 1, 0.5},
+		{color.RGBA{0, 0, 255, 255}, 240, 1, 0.5},
+		{color.RGBA{255, 255, 255, 255}, 0, 0, 1},
+		{color.RGBA{0, 0, 0, 255}, 0, 0, 0},
+		{color.RGBA{128, 64, 32, 10}, 20, 0.6, 0.3137},
+	}
+	for _, tt := range tests {
+		h, s, l := ToHSL(&tt.c)
+		if math.Abs(h-tt.h) > 0.5 || math.Abs(s-tt.s) > 0.01 || math.Abs(l-tt.l) > 0.01 {
+			t.Errorf("ToHSL(%v) = %f, %f, %f, want %f, %f, %f", tt.c, h, s, l, tt.h, tt.s, tt.l)
+		}
+		if got := FromHSL(h, s, l, tt.c.A); *got != tt.c {
+			t.Errorf("FromHSL(ToHSL(%v)) = %v", tt.c, *got)
+		}
+	}
+}
+
+func TestLuminance(t *testing.T) {
+	if got := Luminance(&color.RGBA{255, 255, 255, 255}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("white has luminance %f, want 1", got)
+	}
+	if Luminance(&color.RGBA{0, 255, 0, 255}) <= Luminance(&color.RGBA{255, 0, 0, 255}) {
+		t.Error("green should be brighter than red")
+	}
+}
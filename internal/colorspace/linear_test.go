@@ -0,0 +1,34 @@
+package colorspace
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLinearRoundTrip(t *testing.T) {
+	for i := 0; i <= 255; i++ {
+		v := float64(i) / 255
+		if got := LinearToSRGB(SRGBToLinear(v)); math.Abs(got-v) > 1e-9 {
+			t.Errorf("%d: round trip gave %g, want %g", i, got*255, v*255)
+		}
+	}
+	// mid grey is about a fifth of the light of white
+	if got := SRGBToLinear(0.5); math.Abs(got-0.214) > 0.001 {
+		t.Errorf("got %g for sRGB 0.5", got)
+	}
+}
+
+func TestOKLabRoundTrip(t *testing.T) {
+	for _, c := range [][3]float64{{0, 0, 0}, {1, 1, 1}, {1, 0, 0}, {0.2, 0.5, 0.9}} {
+		L, a, b := LinearToOKLab(c[0], c[1], c[2])
+		r, g, bl := OKLabToLinear(L, a, b)
+		for i, v := range [3]float64{r, g, bl} {
+			if math.Abs(v-c[i]) > 1e-6 {
+				t.Errorf("%v: channel %d round tripped to %g", c, i, v)
+			}
+		}
+	}
+	if L, _, _ := LinearToOKLab(1, 1, 1); math.Abs(L-1) > 1e-6 {
+		t.Errorf("white has lightness %g, want 1", L)
+	}
+}
@@ -1,11 +1,81 @@
 package config
 
 type Config struct {
-	RandomModel bool `default:"false"`
-	ImageWidth  int  `default:"1920"`
-	ImageHeight int  `default:"1080"`
-	FrameCount  int  `default:"90"`
-	StreamKey   string
-	DumpDir     string
-	LogLevel    string `default:"debug"`
+	RandomModel          bool   `default:"false"`
+	Model                string `default:"default"`
+	CachedModels         bool
+	ModelRotate          int `default:"0"`
+	Models               []string
+	ColorMindConcurrency int    `default:"1"`
+	ColorMindRetries     int    `default:"2"`
+	ColorMindTimeout     int    `default:"5"`
+	OfflineAfter         int    `default:"0"`
+	ResetSeedEvery       int    `default:"0"`
+	FetchMode            string `default:"continuous"`
+	ColorMindURL         string `default:"http://colormind.io"`
+	ColorMindAPIPath     string `default:"/api/"`
+	ColorMindListPath    string `default:"/list"`
+	PreloadPalettes      string
+	ImageWidth           int  `default:"1920"`
+	ImageHeight          int  `default:"1080"`
+	AdaptiveResolution   bool `default:"false"`
+	MinWidth             int  `default:"640"`
+	MinHeight            int  `default:"360"`
+	FrameRate            int  `default:"30"`
+	TargetFPS            float64
+	VideoCodec           string `default:"libx264"`
+	VideoBitrate         string `default:"6000k"`
+	Preset               string `default:"veryfast"`
+	Profile              string
+	ConfigFile           string
+	FrameCount           int     `default:"90"`
+	TransitionSeconds    float64 `default:"0"`
+	Easing               string  `default:"linear"`
+	Effect               string  `default:"scroll"`
+	Stops                int     `default:"5"`
+	Pairing              string  `default:"overlap"`
+	SortPalette          string  `default:"none"`
+	MinColorDistance     float64 `default:"0"`
+	HueRange             string
+	MinLuminance         float64 `default:"0"`
+	MaxLuminance         float64 `default:"1"`
+	DwellFrames          int     `default:"0"`
+	MaxDeltaPerFrame     int     `default:"0"`
+	TemporalDither       bool    `default:"false"`
+	Dither               bool    `default:"false"`
+	StopColors           []string
+	Premultiply          bool
+	Alpha                int    `default:"255"`
+	ColorProfile         string `default:"srgb"`
+	Smooth               bool
+	HueRotate            float64 `default:"0"`
+	Grain                float64 `default:"0"`
+	NoiseIntensity       float64 `default:"0"`
+	Seed                 int64   `default:"0"`
+	ROI                  string
+	ROIFill              string  `default:"#000000"`
+	Angle                float64 `default:"0"`
+	ScrollSpeed          float64 `default:"0"`
+	StreamKey            string
+	StrictStreamKey      bool `default:"false"`
+	OutputURL            string
+	OutputRestarts       int `default:"5"`
+	IngestRegion         string
+	IngestLowestLatency  bool   `default:"false"`
+	Sink                 string `default:"ffmpeg"`
+	SinkPath             string `default:"-"`
+	EncoderWorkers       int    `default:"0"`
+	PrebufferFrames      int    `default:"0"`
+	DriftInterval        int    `default:"0"`
+	ShutdownTimeout      int    `default:"10"`
+	DumpDir              string
+	DumpRaw              string
+	DumpFrames           string
+	PNGCompression       string `default:"default"`
+	LogLevel             string `default:"debug"`
+	LogSample            int    `default:"0"`
+	LogFormat            string `default:"auto"`
+	HTTPAddr             string
+	ErrorLogSize         int  `default:"50"`
+	Metrics              bool `default:"false"`
 }
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrConfigFile = errors.New("invalid config file")
+
+// Prefix of the environment variables the config is read from.
+const EnvPrefix = "colorrun"
+
+// Reads the config from a YAML (.yaml, .yml) or JSON (.json) file.  Fields the file doesn't
+// set keep their defaults, and environment variables override the file.  Keys are matched
+// to fields ignoring case, dashes and underscores, so image-width, image_width and
+// ImageWidth all set ImageWidth.  Flags are left to the caller to apply on top.
+func LoadFile(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening config file: %w", err)
+	}
+	values := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &values)
+	case ".json":
+		err = json.Unmarshal(b, &values)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", ErrConfigFile, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigFile, err)
+	}
+
+	env := Config{}
+	if err := envconfig.Process(EnvPrefix, &env); err != nil {
+		return nil, fmt.Errorf("parsing environment variables: %w", err)
+	}
+	c := env
+	if err := c.apply(values); err != nil {
+		return nil, err
+	}
+	c.overrideFromEnv(&env)
+	return &c, nil
+}
+
+// Sets the fields named by the keys of values.  The values are passed back through JSON
+// so both formats are converted to the field types the same way.
+func (c *Config) apply(values map[string]any) error {
+	fields := map[string]string{}
+	t := reflect.TypeOf(*c)
+	for i := 0; i < t.NumField(); i++ {
+		fields[fieldKey(t.Field(i).Name)] = t.Field(i).Name
+	}
+	named := make(map[string]any, len(values))
+	for key, value := range values {
+		name, ok := fields[fieldKey(key)]
+		if !ok {
+			return fmt.Errorf("%w: unknown key %q", ErrConfigFile, key)
+		}
+		named[name] = value
+	}
+	b, err := json.Marshal(named)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigFile, err)
+	}
+	if err := json.Unmarshal(b, c); err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigFile, err)
+	}
+	return nil
+}
+
+// Copies the fields whose environment variable is set from env.
+func (c *Config) overrideFromEnv(env *Config) {
+	dst := reflect.ValueOf(c).Elem()
+	src := reflect.ValueOf(env).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		key := strings.ToUpper(EnvPrefix + "_" + dst.Type().Field(i).Name)
+		if _, ok := os.LookupEnv(key); ok {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+func fieldKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+}
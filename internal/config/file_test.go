@@ -0,0 +1,65 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	for _, path := range []string{"testdata/config.yaml", "testdata/config.json"} {
+		c, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		if c.ImageWidth != 1280 || c.ImageHeight != 720 {
+			t.Errorf("%s: got %dx%d, want 1280x720", path, c.ImageWidth, c.ImageHeight)
+		}
+		if c.Effect != "fade" || c.HueRotate != 12.5 || !c.Dither {
+			t.Errorf("%s: got effect %q, hue rotate %g, dither %t", path, c.Effect, c.HueRotate, c.Dither)
+		}
+		if !slices.Equal(c.Models, []string{"ui", "default"}) {
+			t.Errorf("%s: got models %v", path, c.Models)
+		}
+		// Unset keys keep their defaults.
+		if c.FrameRate != 30 || c.Sink != "ffmpeg" {
+			t.Errorf("%s: got frame rate %d and sink %q, want the defaults", path, c.FrameRate, c.Sink)
+		}
+	}
+}
+
+func TestLoadFilePrecedence(t *testing.T) {
+	t.Setenv("COLORRUN_IMAGEWIDTH", "640")
+	t.Setenv("COLORRUN_FRAMERATE", "60")
+	c, err := LoadFile("testdata/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The environment overrides both the file and the defaults.
+	if c.ImageWidth != 640 || c.FrameRate != 60 {
+		t.Errorf("got width %d at %d fps, want 640 at 60", c.ImageWidth, c.FrameRate)
+	}
+	if c.ImageHeight != 720 {
+		t.Errorf("got height %d, want the file's 720", c.ImageHeight)
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"unknown.yaml": "not-a-field: 1\n",
+		"type.json":    `{"ImageWidth": "wide"}`,
+		"format.toml":  "ImageWidth = 1\n",
+	}
+	for name, body := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); !errors.Is(err, ErrConfigFile) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrConfigFile)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrProfile = errors.New("invalid profile")
+
+// Sets the image size and frame rate from an OBS profile (basic.ini) or an SDP file.
+// Fields the file doesn't define are left alone.
+func (c *Config) LoadProfile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening profile: %w", err)
+	}
+	defer f.Close()
+	return c.ReadProfile(f)
+}
+
+// Like LoadProfile, reading the profile from r.  SDP is detected by its v= first line.
+func (c *Config) ReadProfile(r io.Reader) error {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading profile: %w", err)
+	}
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "v=") {
+		return c.readSDP(lines)
+	}
+	return c.readOBS(lines)
+}
+
+// Reads the scaled output size and the frame rate from the [Video] section.  OBS stores
+// the frame rate one of three ways, chosen by FPSType.
+func (c *Config) readOBS(lines []string) error {
+	video := map[string]string{}
+	section := ""
+	for _, line := range lines {
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && section == "Video" {
+			video[key] = value
+		}
+	}
+	if len(video) == 0 {
+		return fmt.Errorf("%w: no [Video] section", ErrProfile)
+	}
+	ints := map[string]int{}
+	for _, key := range []string{"OutputCX", "OutputCY", "FPSType", "FPSInt", "FPSNum", "FPSDen"} {
+		v, ok := video[key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrProfile, key, err)
+		}
+		ints[key] = n
+	}
+	if w, ok := ints["OutputCX"]; ok {
+		c.ImageWidth = w
+	}
+	if h, ok := ints["OutputCY"]; ok {
+		c.ImageHeight = h
+	}
+	switch ints["FPSType"] {
+	case 0:
+		if v, ok := video["FPSCommon"]; ok {
+			fps, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("%w: FPSCommon: %w", ErrProfile, err)
+			}
+			c.FrameRate = int(math.Round(fps))
+		}
+	case 1:
+		if fps, ok := ints["FPSInt"]; ok {
+			c.FrameRate = fps
+		}
+	case 2:
+		if ints["FPSDen"] > 0 {
+			c.FrameRate = int(math.Round(float64(ints["FPSNum"]) / float64(ints["FPSDen"])))
+		}
+	}
+	return nil
+}
+
+// Reads the a=framesize and a=framerate attributes
+func (c *Config) readSDP(lines []string) error {
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "a=framerate:"):
+			fps, err := strconv.ParseFloat(strings.TrimPrefix(line, "a=framerate:"), 64)
+			if err != nil {
+				return fmt.Errorf("%w: framerate: %w", ErrProfile, err)
+			}
+			c.FrameRate = int(math.Round(fps))
+		case strings.HasPrefix(line, "a=framesize:"):
+			// a=framesize:<payload type> <width>-<height>
+			_, size, _ := strings.Cut(strings.TrimPrefix(line, "a=framesize:"), " ")
+			w, h, ok := strings.Cut(size, "-")
+			width, errW := strconv.Atoi(w)
+			height, errH := strconv.Atoi(h)
+			if !ok || errW != nil || errH != nil {
+				return fmt.Errorf("%w: framesize %q", ErrProfile, size)
+			}
+			c.ImageWidth = width
+			c.ImageHeight = height
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	tests := []struct {
+		path                 string
+		width, height, frame int
+	}{
+		{"testdata/basic.ini", 1280, 720, 60},
+		{"testdata/stream.sdp", 640, 360, 25},
+	}
+	for _, tt := range tests {
+		c := Config{ImageWidth: 1920, ImageHeight: 1080, FrameRate: 30}
+		if err := c.LoadProfile(tt.path); err != nil {
+			t.Fatalf("%s: %s", tt.path, err)
+		}
+		if c.ImageWidth != tt.width || c.ImageHeight != tt.height || c.FrameRate != tt.frame {
+			t.Errorf("%s: got %dx%d at %d fps, want %dx%d at %d fps", tt.path, c.ImageWidth, c.ImageHeight, c.FrameRate, tt.width, tt.height, tt.frame)
+		}
+	}
+}
+
+func TestReadProfileFPSTypes(t *testing.T) {
+	tests := []struct {
+		video string
+		want  int
+	}{
+		{"FPSType=0\nFPSCommon=29.97", 30},
+		{"FPSType=1\nFPSInt=48", 48},
+		{"FPSType=2\nFPSNum=120\nFPSDen=2", 60},
+	}
+	for _, tt := range tests {
+		c := Config{FrameRate: 1}
+		if err := c.ReadProfile(strings.NewReader("[Video]\n" + tt.video)); err != nil {
+			t.Fatalf("%q: %s", tt.video, err)
+		}
+		if c.FrameRate != tt.want {
+			t.Errorf("%q: got %d fps, want %d", tt.video, c.FrameRate, tt.want)
+		}
+	}
+	c := Config{}
+	if err := c.ReadProfile(strings.NewReader("[General]\nName=x")); !errors.Is(err, ErrProfile) {
+		t.Errorf("got %v, want %v", err, ErrProfile)
+	}
+}
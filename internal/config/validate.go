@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalid = errors.New("invalid config")
+
+// Checks the settings the pipeline can't start without, returning the first problem
+// wrapping ErrInvalid.
+func (c *Config) Validate() error {
+	switch {
+	case c.ImageWidth < 1 || c.ImageHeight < 1:
+		return fmt.Errorf("%w: image size %dx%d must be positive", ErrInvalid, c.ImageWidth, c.ImageHeight)
+	case c.FrameCount < 1:
+		return fmt.Errorf("%w: frame count %d must be positive", ErrInvalid, c.FrameCount)
+	case c.FrameRate < 1:
+		return fmt.Errorf("%w: frame rate %d must be positive", ErrInvalid, c.FrameRate)
+	case c.VideoBitrate == "":
+		return fmt.Errorf("%w: video bitrate not set", ErrInvalid)
+	case c.Alpha < 0 || c.Alpha > 255:
+		return fmt.Errorf("%w: alpha %d must be from 0 to 255", ErrInvalid, c.Alpha)
+	case c.AdaptiveResolution && (c.MinWidth < 1 || c.MinHeight < 1 || c.MinWidth > c.ImageWidth || c.MinHeight > c.ImageHeight):
+		return fmt.Errorf("%w: minimum size %dx%d must be positive and no bigger than the image", ErrInvalid, c.MinWidth, c.MinHeight)
+	case c.TargetFPS < 0:
+		return fmt.Errorf("%w: target fps %g must not be negative", ErrInvalid, c.TargetFPS)
+	case c.EncoderWorkers < 0:
+		return fmt.Errorf("%w: encoder workers %d must not be negative", ErrInvalid, c.EncoderWorkers)
+	case c.NoiseIntensity < 0 || c.NoiseIntensity > 1:
+		return fmt.Errorf("%w: noise intensity %g must be from 0 to 1", ErrInvalid, c.NoiseIntensity)
+	case c.MinLuminance < 0 || c.MaxLuminance > 1 || c.MinLuminance > c.MaxLuminance:
+		return fmt.Errorf("%w: luminance range %g to %g must be within 0 to 1", ErrInvalid, c.MinLuminance, c.MaxLuminance)
+	}
+	// Only ffmpeg streams anywhere, the other sinks write to a file.
+	if c.Sink == "ffmpeg" {
+		switch {
+		case c.StreamKey == "" && c.OutputURL == "":
+			return fmt.Errorf("%w: stream key or output url not set", ErrInvalid)
+		case c.StreamKey != "" && c.OutputURL != "":
+			return fmt.Errorf("%w: only one of stream key and output url can be set", ErrInvalid)
+		}
+	}
+	return nil
+}
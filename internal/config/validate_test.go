@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	valid := func() Config {
+		return Config{
+			ImageWidth:   1920,
+			ImageHeight:  1080,
+			FrameCount:   90,
+			FrameRate:    30,
+			VideoBitrate: "6000k",
+			Sink:         "ffmpeg",
+			StreamKey:    "live_123",
+			MaxLuminance: 1,
+			Alpha:        255,
+		}
+	}
+	tests := map[string]func(*Config){
+		"zero width":      func(c *Config) { c.ImageWidth = 0 },
+		"negative height": func(c *Config) { c.ImageHeight = -1 },
+		"zero frames":     func(c *Config) { c.FrameCount = 0 },
+		"zero fps":        func(c *Config) { c.FrameRate = 0 },
+		"no bitrate":      func(c *Config) { c.VideoBitrate = "" },
+		"no output":       func(c *Config) { c.StreamKey = "" },
+		"both outputs":    func(c *Config) { c.OutputURL = "rtmp://localhost/live" },
+		"luminance order": func(c *Config) { c.MinLuminance, c.MaxLuminance = 0.8, 0.2 },
+		"luminance range": func(c *Config) { c.MaxLuminance = 1.5 },
+		"alpha":           func(c *Config) { c.Alpha = 256 },
+		"min size":        func(c *Config) { c.AdaptiveResolution, c.MinWidth, c.MinHeight = true, 3840, 360 },
+		"target fps":      func(c *Config) { c.TargetFPS = -1 },
+		"noise":           func(c *Config) { c.NoiseIntensity = 1.5 },
+		"encoder workers": func(c *Config) { c.EncoderWorkers = -1 },
+	}
+	for name, breakIt := range tests {
+		c := valid()
+		breakIt(&c)
+		if err := c.Validate(); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want %v", name, err, ErrInvalid)
+		}
+	}
+	c := valid()
+	if err := c.Validate(); err != nil {
+		t.Errorf("valid config: %s", err)
+	}
+	// the raw sink doesn't stream so needs neither
+	c.Sink = "raw"
+	c.StreamKey = ""
+	if err := c.Validate(); err != nil {
+		t.Errorf("raw sink: %s", err)
+	}
+}
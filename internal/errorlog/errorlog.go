@@ -0,0 +1,76 @@
+package errorlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Category string    `json:"category"`
+	Error    string    `json:"error"`
+}
+
+// Keeps the most recent errors in memory
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	// index the next entry is written to
+	next int
+	full bool
+}
+
+func New(size int) *Ring {
+	if size < 1 {
+		size = 1
+	}
+	return &Ring{
+		entries: make([]Entry, size),
+	}
+}
+
+// Records the error.  Nil errors are ignored.
+func (r *Ring) Add(category string, err error) {
+	if err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = Entry{
+		Time:     time.Now(),
+		Category: category,
+		Error:    err.Error(),
+	}
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Returns the stored entries, oldest first
+func (r *Ring) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Entry{}, r.entries[:r.next]...)
+	}
+	out := make([]Entry, 0, len(r.entries))
+	out = append(out, r.entries[r.next:]...)
+	return append(out, r.entries[:r.next]...)
+}
+
+// Serves the stored entries as JSON
+func (r *Ring) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.Entries()); err != nil {
+		log.Error().Err(err).Msg("writing error log response")
+	}
+}
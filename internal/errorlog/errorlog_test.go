@@ -0,0 +1,68 @@
+package errorlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRingEndpoint(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		added int
+		want  []string
+	}{
+		{"empty", 3, 0, []string{}},
+		{"partial", 3, 2, []string{"error 0", "error 1"}},
+		{"full", 3, 3, []string{"error 0", "error 1", "error 2"}},
+		{"wrapped", 3, 7, []string{"error 4", "error 5", "error 6"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.size)
+			for i := 0; i < tt.added; i++ {
+				r.Add("test", fmt.Errorf("error %d", i))
+			}
+			r.Add("test", nil)
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+			resp, err := http.Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("got status %d", resp.StatusCode)
+			}
+			entries := []Entry{}
+			if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+				t.Fatalf("decoding: %s", err)
+			}
+			if len(entries) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(tt.want))
+			}
+			for i, e := range entries {
+				if e.Error != tt.want[i] || e.Category != "test" || e.Time.IsZero() {
+					t.Errorf("entry %d is %+v, want error %q", i, e, tt.want[i])
+				}
+				if i > 0 && e.Time.Before(entries[i-1].Time) {
+					t.Errorf("entry %d is older than the one before it", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRingMethod(t *testing.T) {
+	r := New(1)
+	r.Add("test", errors.New("boom"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/errors", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,263 @@
+package frame
+
+import (
+	"context"
+	"image"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// Frames made at this fraction of the target frame rate are keeping up
+	adaptiveTolerance = 0.95
+	// Left spare when stepping down, so the next size isn't only just fast enough
+	adaptiveHeadroom = 0.9
+)
+
+// Steps the size frames are rendered at down while they're made slower than the target
+// frame rate, for ffmpeg or an AdaptiveMaker to scale back up.  Rendering costs about the
+// same for each pixel, so the pixels are cut by the shortfall in one step.  The size
+// never steps back up, as frames which keep up can't say how much faster they could be
+// made.
+type AdaptiveResolution struct {
+	// Size of the stream, which frames are rendered at until they fall behind
+	Full image.Point
+	// Smallest size rendered at, the width and height are each kept to at least these
+	Min       image.Point
+	TargetFPS float64
+	size      image.Point
+}
+
+// The size to render at, Full until Update has stepped it down
+func (ar *AdaptiveResolution) Size() image.Point {
+	if ar.size == (image.Point{}) {
+		return ar.Full
+	}
+	return ar.size
+}
+
+// Takes the frame rate reached at the current size and returns the size to render at
+// next, and whether it's changed.  Sizes keep the aspect ratio of Full, other than
+// where Min holds them, and are rounded down to even numbers for ffmpeg's yuv420p.
+func (ar *AdaptiveResolution) Update(fps float64) (image.Point, bool) {
+	size := ar.Size()
+	if fps <= 0 || fps >= ar.TargetFPS*adaptiveTolerance {
+		return size, false
+	}
+	scale := math.Sqrt(fps / ar.TargetFPS * adaptiveHeadroom)
+	next := image.Pt(
+		min(max(int(float64(size.X)*scale)&^1, ar.Min.X), size.X),
+		min(max(int(float64(size.Y)*scale)&^1, ar.Min.Y), size.Y),
+	)
+	if next == size {
+		return size, false
+	}
+	ar.size = next
+	return next, true
+}
+
+// Times frames from a maker at each size until they're made fast enough, or the size
+// can't go any smaller, and returns the size.  newMaker returns a maker, reset, rendering
+// at the size, which is run for window and then closed.
+func CalibrateResolution(ctx context.Context, ar *AdaptiveResolution, window time.Duration, newMaker func(size image.Point) Maker) image.Point {
+	for ctx.Err() == nil {
+		if _, changed := ar.Update(timeMaker(ctx, newMaker(ar.Size()), window)); !changed {
+			break
+		}
+	}
+	return ar.Size()
+}
+
+// Returns the frames per second read from the maker over window
+func timeMaker(ctx context.Context, m Maker, window time.Duration) float64 {
+	ctx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+	size := m.Bounds().Size()
+	counter := &Counter{R: m, FrameSize: size.X * size.Y * 4}
+	done := make(chan struct{})
+	go func() {
+		RunContext(ctx, m)
+		close(done)
+	}()
+	start := time.Now()
+	io.Copy(io.Discard, counter)
+	<-done
+	return float64(counter.Frames()) / time.Since(start).Seconds()
+}
+
+// Renders frames with a maker from New at the size Resolution gives and scales them
+// up, nearest neighbour, to the size Resolution had when the AdaptiveMaker was first
+// read, so the frames read never change size as rendering steps down.  Update steps
+// down while the stream runs, swapping in a new maker between frames, which starts
+// from the next colors rather than carrying on the old maker's transition.
+type AdaptiveMaker struct {
+	Resolution *AdaptiveResolution
+	// Returns a maker, reset, rendering at the size
+	New func(size image.Point) Maker
+	mu  sync.Mutex
+	// maker read from, and the one swapped in at the start of the next frame
+	current Maker
+	next    Maker
+	paused  bool
+	closed  bool
+	// size of the frames read, fixed by the first maker
+	size image.Point
+	// the frame being read, and bytes of it read
+	frame []byte
+	small []byte
+	idx   int
+}
+
+// Makes the first maker if it hasn't been made, and returns the current one
+func (am *AdaptiveMaker) maker() Maker {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	if am.current == nil {
+		am.size = am.Resolution.Size()
+		am.current = am.New(am.size)
+	}
+	return am.current
+}
+
+// Takes the frame rate the stream is running at and steps the size rendered at down
+// when it's behind, returning the size and whether it's changed.  Safe to call while
+// frames are read.
+func (am *AdaptiveMaker) Update(fps float64) (image.Point, bool) {
+	am.maker()
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	size, changed := am.Resolution.Update(fps)
+	if !changed || am.closed {
+		return size, false
+	}
+	if am.next != nil {
+		am.next.Close()
+	}
+	am.next = am.New(size)
+	if am.paused {
+		am.next.Pause()
+	}
+	return size, true
+}
+
+// Runs each maker in turn as it's swapped in, until the AdaptiveMaker is closed
+func (am *AdaptiveMaker) Run() {
+	m := am.maker()
+	for {
+		m.Run()
+		am.mu.Lock()
+		if am.closed || m == am.current {
+			am.mu.Unlock()
+			return
+		}
+		m = am.current
+		am.mu.Unlock()
+	}
+}
+
+func (am *AdaptiveMaker) Read(out []byte) (int, error) {
+	if am.idx == 0 {
+		if err := am.render(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(out, am.frame[am.idx:])
+	am.idx = (am.idx + n) % len(am.frame)
+	return n, nil
+}
+
+// Swaps in the next maker if there is one, then reads a frame and scales it up
+func (am *AdaptiveMaker) render() error {
+	m := am.maker()
+	am.mu.Lock()
+	if am.next != nil {
+		m.Close()
+		m, am.current, am.next = am.next, am.next, nil
+	}
+	am.mu.Unlock()
+	am.frame = resize(am.frame, am.size.X*am.size.Y*4)
+	src := m.Bounds().Size()
+	if src == am.size {
+		_, err := io.ReadFull(m, am.frame)
+		return err
+	}
+	am.small = resize(am.small, src.X*src.Y*4)
+	if _, err := io.ReadFull(m, am.small); err != nil {
+		return err
+	}
+	row := am.size.X * 4
+	prevY := -1
+	for y := 0; y < am.size.Y; y++ {
+		dst := am.frame[y*row : (y+1)*row]
+		sy := y * src.Y / am.size.Y
+		if sy == prevY {
+			copy(dst, am.frame[(y-1)*row:y*row])
+			continue
+		}
+		prevY = sy
+		srcRow := am.small[sy*src.X*4 : (sy+1)*src.X*4]
+		for x := 0; x < am.size.X; x++ {
+			sx := x * src.X / am.size.X * 4
+			copy(dst[x*4:x*4+4], srcRow[sx:sx+4])
+		}
+	}
+	return nil
+}
+
+// Returns b with length n, reallocated only when it's too small
+func resize(b []byte, n int) []byte {
+	if cap(b) < n {
+		return make([]byte, n)
+	}
+	return b[:n]
+}
+
+func (am *AdaptiveMaker) Close() error {
+	am.maker()
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.closed = true
+	if am.next != nil {
+		am.next.Close()
+		am.next = nil
+	}
+	return am.current.Close()
+}
+
+func (am *AdaptiveMaker) Reset() {
+	am.idx = 0
+	am.maker().Reset()
+}
+
+func (am *AdaptiveMaker) Pause() {
+	am.maker()
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.paused = true
+	if am.next != nil {
+		am.next.Pause()
+	}
+	am.current.Pause()
+}
+
+func (am *AdaptiveMaker) Resume() {
+	am.maker()
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.paused = false
+	if am.next != nil {
+		am.next.Resume()
+	}
+	am.current.Resume()
+}
+
+func (am *AdaptiveMaker) Bounds() image.Rectangle {
+	am.maker()
+	return image.Rectangle{Max: am.size}
+}
+
+func (am *AdaptiveMaker) PixelFormat() string {
+	return am.maker().PixelFormat()
+}
@@ -0,0 +1,184 @@
+package frame
+
+import (
+	"context"
+	"image"
+	"io"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveResolutionUpdate(t *testing.T) {
+	ar := &AdaptiveResolution{Full: image.Pt(1920, 1080), Min: image.Pt(640, 360), TargetFPS: 30}
+	if size, changed := ar.Update(29); changed || size != ar.Full {
+		t.Errorf("keeping up changed the size to %v", size)
+	}
+	// a quarter of the frame rate needs under a quarter of the pixels
+	size, changed := ar.Update(7.5)
+	if !changed || size.X*size.Y > 1920*1080/4 || size.X%2 != 0 || size.Y%2 != 0 {
+		t.Errorf("at a quarter of the frame rate got %v, want an even size under a quarter of the pixels", size)
+	}
+	if ratio := float64(size.X) / float64(size.Y); math.Abs(ratio-16.0/9) > 0.02 {
+		t.Errorf("%v lost the aspect ratio", size)
+	}
+	// held at the minimum
+	if size, _ = ar.Update(1); size != ar.Min {
+		t.Errorf("far behind got %v, want the minimum %v", size, ar.Min)
+	}
+	if _, changed = ar.Update(1); changed {
+		t.Error("stepped down past the minimum")
+	}
+	if ar.Size() != ar.Min {
+		t.Errorf("size is %v, want %v", ar.Size(), ar.Min)
+	}
+}
+
+// Makes blank frames, taking as long for each as pixels per second allows, like a
+// renderer or encoder which can't keep up
+type slowMaker struct {
+	rect            image.Rectangle
+	pixelsPerSecond int
+	mu              sync.Mutex
+	closed          chan struct{}
+	closeOnce       sync.Once
+	left            int
+}
+
+func newSlowMaker(size image.Point, pixelsPerSecond int) *slowMaker {
+	return &slowMaker{rect: image.Rectangle{Max: size}, pixelsPerSecond: pixelsPerSecond, closed: make(chan struct{})}
+}
+
+func (sm *slowMaker) Read(b []byte) (int, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.left == 0 {
+		select {
+		case <-sm.closed:
+			return 0, io.EOF
+		default:
+		}
+		pixels := sm.rect.Dx() * sm.rect.Dy()
+		time.Sleep(time.Duration(pixels) * time.Second / time.Duration(sm.pixelsPerSecond))
+		sm.left = pixels * 4
+	}
+	n := min(len(b), sm.left)
+	clear(b[:n])
+	sm.left -= n
+	return n, nil
+}
+
+func (sm *slowMaker) Close() error {
+	sm.closeOnce.Do(func() { close(sm.closed) })
+	return nil
+}
+
+func (sm *slowMaker) Run()                    { <-sm.closed }
+func (sm *slowMaker) Reset()                  {}
+func (sm *slowMaker) Pause()                  {}
+func (sm *slowMaker) Resume()                 {}
+func (sm *slowMaker) Bounds() image.Rectangle { return sm.rect }
+func (sm *slowMaker) PixelFormat() string     { return "rgba" }
+
+func TestCalibrateResolution(t *testing.T) {
+	// 30 frames a second of 100x75, so a quarter of the frame rate at full size
+	pixelsPerSecond := 30 * 100 * 75
+	ar := &AdaptiveResolution{Full: image.Pt(200, 150), Min: image.Pt(40, 30), TargetFPS: 30}
+	var tried []image.Point
+	size := CalibrateResolution(context.Background(), ar, 300*time.Millisecond, func(size image.Point) Maker {
+		tried = append(tried, size)
+		return newSlowMaker(size, pixelsPerSecond)
+	})
+	if size.X >= 200 || size.Y >= 150 || size.X < 40 || size.Y < 30 {
+		t.Fatalf("calibrated to %v, want between %v and %v", size, ar.Min, ar.Full)
+	}
+	if fps := float64(pixelsPerSecond) / float64(size.X*size.Y); fps < 30*adaptiveTolerance {
+		t.Errorf("calibrated to %v, which makes %.1f frames a second, want 30", size, fps)
+	}
+	if len(tried) < 2 || tried[0] != ar.Full {
+		t.Errorf("tried %v, want the full size then smaller ones", tried)
+	}
+}
+
+// Makes frames with each pixel's position and the frame's width in it
+type patternMaker struct {
+	*slowMaker
+	idx int
+}
+
+func (pm *patternMaker) Read(b []byte) (int, error) {
+	select {
+	case <-pm.closed:
+		return 0, io.EOF
+	default:
+	}
+	size := pm.rect.Size()
+	for i := range b {
+		p := pm.idx / 4
+		b[i] = []byte{byte(p % size.X), byte(p / size.X), byte(size.X), 255}[pm.idx%4]
+		pm.idx = (pm.idx + 1) % (size.X * size.Y * 4)
+	}
+	return len(b), nil
+}
+
+func TestAdaptiveMaker(t *testing.T) {
+	full := image.Pt(16, 8)
+	am := &AdaptiveMaker{
+		Resolution: &AdaptiveResolution{Full: full, Min: image.Pt(2, 2), TargetFPS: 30},
+		New: func(size image.Point) Maker {
+			return &patternMaker{slowMaker: newSlowMaker(size, 1)}
+		},
+	}
+	done := make(chan struct{})
+	go func() {
+		am.Run()
+		close(done)
+	}()
+	// midway is called once part of the frame is read
+	check := func(src image.Point, chunk int, midway func()) {
+		t.Helper()
+		got := make([]byte, full.X*full.Y*4)
+		for i := 0; i < len(got); i += chunk {
+			if i == chunk {
+				midway()
+			}
+			if _, err := io.ReadFull(am, got[i:min(i+chunk, len(got))]); err != nil {
+				t.Fatalf("reading: %s", err)
+			}
+		}
+		for y := 0; y < full.Y; y++ {
+			for x := 0; x < full.X; x++ {
+				i := (y*full.X + x) * 4
+				want := []byte{byte(x * src.X / full.X), byte(y * src.Y / full.Y), byte(src.X), 255}
+				if string(got[i:i+4]) != string(want) {
+					t.Fatalf("pixel %d,%d rendered at %v is % x, want % x", x, y, src, got[i:i+4], want)
+				}
+			}
+		}
+	}
+	check(full, 7, func() {
+		if _, changed := am.Update(29); changed {
+			t.Error("keeping up changed the size")
+		}
+	})
+	var size image.Point
+	// the frame part read when the size steps down is finished at the old size
+	check(full, 5, func() {
+		var changed bool
+		size, changed = am.Update(7.5)
+		if !changed || size.X >= full.X || size.Y >= full.Y {
+			t.Fatalf("at a quarter of the frame rate got %v, want smaller than %v", size, full)
+		}
+	})
+	if am.Bounds().Size() != full {
+		t.Errorf("bounds changed to %v, want %v", am.Bounds().Size(), full)
+	}
+	check(size, 5, func() {})
+	am.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("still running after closing")
+	}
+}
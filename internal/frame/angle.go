@@ -0,0 +1,64 @@
+package frame
+
+import (
+	"image"
+	"math"
+)
+
+// Whether the gradient runs in any direction but left to right
+func (lgis *LinearGradient) angled() bool {
+	return math.Mod(lgis.Angle, 360) != 0
+}
+
+// Frames buffered between Run and Read.  Rows are cheap so three transitions' worth
+// are, whole frames are not.
+func (lgis *LinearGradient) bufferSize() int {
+	if lgis.angled() {
+		return fullFrameBuffer
+	}
+	return max(lgis.Transition*3, 0)
+}
+
+// Returns the length in pixels of the axis the gradient runs along and, when it's at
+// an angle, the position on it of each pixel of the gradient rect, row by row.
+// Positions are nil when the gradient runs left to right and rows can be repeated.
+func (lgis *LinearGradient) axis() (int, []int) {
+	rect := lgis.gradientRect()
+	if !lgis.angled() {
+		return rect.Dx(), nil
+	}
+	w, h := float64(rect.Dx()), float64(rect.Dy())
+	sin, cos := math.Sincos(lgis.Angle * math.Pi / 180)
+	// the corner the gradient starts from projects onto the start of the axis
+	start := min(0, w*cos) + min(0, h*sin)
+	length := max(1, int(math.Ceil(math.Abs(w*cos)+math.Abs(h*sin)-1e-9)))
+	pos := make([]int, rect.Dx()*rect.Dy())
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			p := (float64(x)+0.5)*cos + (float64(y)+0.5)*sin - start
+			pos[y*rect.Dx()+x] = min(max(int(p), 0), length-1)
+		}
+	}
+	return length, pos
+}
+
+// Renders the whole frame, filling it and then coloring each pixel of the gradient
+// rect from its position on the axis
+func (lgis *LinearGradient) renderAngled(row *image.RGBA, fill []byte, axis []int) *image.RGBA {
+	img := lgis.frame(lgis.Rect.Dx(), lgis.Rect.Dy())
+	if fill != nil {
+		for y := 0; y < img.Rect.Dy(); y++ {
+			copy(img.Pix[y*img.Stride:], fill[:img.Stride])
+		}
+	}
+	rect := lgis.gradientRect().Sub(lgis.Rect.Min)
+	for y := 0; y < rect.Dy(); y++ {
+		out := img.Pix[(rect.Min.Y+y)*img.Stride+rect.Min.X*4:]
+		// dithered gradients have a row for each row of the pattern
+		in := row.Pix[(y%row.Rect.Dy())*row.Stride:]
+		for x, p := range axis[y*rect.Dx() : (y+1)*rect.Dx()] {
+			copy(out[x*4:x*4+4], in[p*4:p*4+4])
+		}
+	}
+	return img
+}
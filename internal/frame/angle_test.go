@@ -0,0 +1,75 @@
+package frame
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestLinearGradientAngle(t *testing.T) {
+	palette := make([]*color.RGBA, len(testColors))
+	for i := range testColors {
+		palette[i] = &testColors[i]
+	}
+	size := 8
+	tests := []struct {
+		angle float64
+		// pairs of corners which should match, and which shouldn't
+		same [2]image.Point
+		diff [2]image.Point
+	}{
+		{0, [2]image.Point{{0, 0}, {0, size - 1}}, [2]image.Point{{0, 0}, {size - 1, 0}}},
+		{90, [2]image.Point{{0, 0}, {size - 1, 0}}, [2]image.Point{{0, 0}, {0, size - 1}}},
+		{45, [2]image.Point{{size - 1, 0}, {0, size - 1}}, [2]image.Point{{0, 0}, {size - 1, size - 1}}},
+	}
+	for _, tt := range tests {
+		lg := &LinearGradient{
+			ColorChannel: colorChannel(testColors...),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, size, size),
+			Palette:      palette,
+			Angle:        tt.angle,
+		}
+		frame := lg.FrameAt(0)
+		if a, b := frame.RGBAAt(tt.same[0].X, tt.same[0].Y), frame.RGBAAt(tt.same[1].X, tt.same[1].Y); a != b {
+			t.Errorf("%g°: %v is %v and %v is %v, want them equal", tt.angle, tt.same[0], a, tt.same[1], b)
+		}
+		if a, b := frame.RGBAAt(tt.diff[0].X, tt.diff[0].Y), frame.RGBAAt(tt.diff[1].X, tt.diff[1].Y); a == b {
+			t.Errorf("%g°: %v and %v are both %v", tt.angle, tt.diff[0], tt.diff[1], a)
+		}
+		// the gradient starts from the first color whichever way it runs
+		if got := frame.RGBAAt(0, 0); got != testColors[0] {
+			t.Errorf("%g°: starting corner is %v, want %v", tt.angle, got, testColors[0])
+		}
+		lg.Reset()
+		go lg.Run()
+		frames := readInChunks(t, lg, 4096)
+		if !bytes.Equal(frames[:len(frame.Pix)], frame.Pix) {
+			t.Errorf("%g°: the first frame of Run differs from FrameAt(0)", tt.angle)
+		}
+	}
+}
+
+func TestLinearGradientAngleROI(t *testing.T) {
+	fill := color.RGBA{1, 2, 3, 255}
+	lg := &LinearGradient{
+		Rect:    image.Rect(0, 0, 10, 10),
+		Palette: []*color.RGBA{&testColors[0], &testColors[1]},
+		Angle:   90,
+		ROI:     image.Rect(2, 3, 8, 9),
+		Fill:    fill,
+	}
+	frame := lg.FrameAt(0)
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			inside := image.Pt(x, y).In(lg.ROI)
+			if got := frame.RGBAAt(x, y); (got == fill) == inside {
+				t.Fatalf("pixel %d,%d inside %t is %v", x, y, inside, got)
+			}
+		}
+	}
+	if got := frame.RGBAAt(2, 3); got != testColors[0] {
+		t.Errorf("top of the region is %v, want %v", got, testColors[0])
+	}
+}
@@ -0,0 +1,41 @@
+package frame
+
+import "image/color"
+
+// Color with float64 channels, used to keep precision until the final quantization
+type rgbaf [4]float64
+
+func toRGBAf(c *color.RGBA) rgbaf {
+	return rgbaf{float64(c.R), float64(c.G), float64(c.B), float64(c.A)}
+}
+
+func mixf(c1 rgbaf, c2 rgbaf, ratio float64) rgbaf {
+	var out rgbaf
+	for i := range out {
+		out[i] = c1[i]*(1.0-ratio) + c2[i]*ratio
+	}
+	return out
+}
+
+// Scales the color channels by alpha, for consumers expecting premultiplied colors
+func (c rgbaf) premultiply() rgbaf {
+	a := c[3] / 255
+	return rgbaf{c[0] * a, c[1] * a, c[2] * a, c[3]}
+}
+
+// Converts back to 8 bits per channel.  The threshold is added before truncating so
+// a value of 0 truncates and a dither threshold moves a channel by at most 1.
+func (c rgbaf) quantize(threshold float64) color.RGBA {
+	var out [4]uint8
+	for i, v := range c {
+		v += threshold
+		if v > 255 {
+			v = 255
+		}
+		if v < 0 {
+			v = 0
+		}
+		out[i] = uint8(v)
+	}
+	return color.RGBA{out[0], out[1], out[2], out[3]}
+}
@@ -0,0 +1,56 @@
+package frame
+
+import (
+	"image/color"
+	"testing"
+)
+
+// The float32 mix the frame makers used before switching to float64
+func mix32(c1 *color.RGBA, c2 *color.RGBA, ratio float32) uint8 {
+	return uint8(float32(c1.R)*(1.0-ratio) + float32(c2.R)*ratio)
+}
+
+// Ramps between every pair of colors a few values apart, one step per value, and
+// counts the steps which don't move by exactly one: a repeat followed by a jump.
+func TestMixPrecision(t *testing.T) {
+	artifacts32, artifacts64 := 0, 0
+	for a := 0; a < 256; a++ {
+		for d := 1; d <= 16 && a+d < 256; d++ {
+			c1 := &color.RGBA{uint8(a), 0, 0, 255}
+			c2 := &color.RGBA{uint8(a + d), 0, 0, 255}
+			prev32, prev64 := c1.R, c1.R
+			for i := 1; i <= d; i++ {
+				v32 := mix32(c1, c2, float32(i)/float32(d))
+				v64 := mix(c1, c2, float64(i)/float64(d)).R
+				if v32-prev32 != 1 {
+					artifacts32++
+				}
+				if v64-prev64 != 1 {
+					artifacts64++
+				}
+				prev32, prev64 = v32, v64
+			}
+		}
+	}
+	if artifacts64 >= artifacts32 {
+		t.Errorf("float64 ramps have %d uneven steps, float32 %d; want fewer", artifacts64, artifacts32)
+	}
+}
+
+func TestPremultiply(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 127}
+	for _, premultiply := range []bool{true, false} {
+		got := readTransitionColors(t, &LinearGradientTransition{
+			ColorChannel: colorChannel(red, red),
+			Transition:   2,
+			Premultiply:  premultiply,
+		})
+		want := red
+		if premultiply {
+			want = color.RGBA{127, 0, 0, 127}
+		}
+		if len(got) == 0 || got[0] != want {
+			t.Errorf("premultiply %t: got %v, want %v", premultiply, got, want)
+		}
+	}
+}
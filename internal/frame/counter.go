@@ -0,0 +1,69 @@
+package frame
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// How long the frame rate is averaged over
+const fpsWindow = time.Second
+
+// Counts the frames read through it and how quickly, safe to query while it's read
+type Counter struct {
+	R         io.Reader
+	FrameSize int
+	read      atomic.Int64
+	mu        sync.Mutex
+	// frames read by markTime, the start of the current window
+	markTime   time.Time
+	markFrames int64
+	fps        float64
+}
+
+func (fc *Counter) Read(b []byte) (int, error) {
+	n, err := fc.R.Read(b)
+	fc.read.Add(int64(n))
+	fc.tick(time.Now())
+	return n, err
+}
+
+// Number of whole frames read
+func (fc *Counter) Frames() int64 {
+	if fc.FrameSize <= 0 {
+		return 0
+	}
+	return fc.read.Load() / int64(fc.FrameSize)
+}
+
+// Frames per second read over the last second or so
+func (fc *Counter) FPS() float64 {
+	return fc.fpsAt(time.Now())
+}
+
+// Closes the window once it's a second long
+func (fc *Counter) tick(now time.Time) {
+	frames := fc.Frames()
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.markTime.IsZero() {
+		fc.markTime, fc.markFrames = now, frames
+		return
+	}
+	if elapsed := now.Sub(fc.markTime); elapsed >= fpsWindow {
+		fc.fps = float64(frames-fc.markFrames) / elapsed.Seconds()
+		fc.markTime, fc.markFrames = now, frames
+	}
+}
+
+func (fc *Counter) fpsAt(now time.Time) float64 {
+	frames := fc.Frames()
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	// reads have stalled, so the last window no longer says how fast frames are going
+	if elapsed := now.Sub(fc.markTime); !fc.markTime.IsZero() && elapsed >= 2*fpsWindow {
+		return float64(frames-fc.markFrames) / elapsed.Seconds()
+	}
+	return fc.fps
+}
@@ -0,0 +1,43 @@
+package frame
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	fc := &Counter{R: bytes.NewReader(make([]byte, 10*16)), FrameSize: 16}
+	buf := make([]byte, 24)
+	// a frame and a half
+	if _, err := io.ReadFull(fc, buf); err != nil {
+		t.Fatal(err)
+	}
+	if n := fc.Frames(); n != 1 {
+		t.Errorf("counted %d frames after a frame and a half, want 1", n)
+	}
+	if _, err := io.Copy(io.Discard, fc); err != nil {
+		t.Fatal(err)
+	}
+	if n := fc.Frames(); n != 10 {
+		t.Errorf("counted %d frames, want 10", n)
+	}
+
+	start := time.Now()
+	fc = &Counter{FrameSize: 16}
+	fc.tick(start)
+	fc.read.Add(30 * 16)
+	fc.tick(start.Add(fpsWindow / 2))
+	if fps := fc.fpsAt(start.Add(fpsWindow / 2)); fps != 0 {
+		t.Errorf("got %f fps before the first window closed, want 0", fps)
+	}
+	fc.tick(start.Add(fpsWindow))
+	if fps := fc.fpsAt(start.Add(fpsWindow)); fps != 30 {
+		t.Errorf("got %f fps, want 30", fps)
+	}
+	// nothing more is read, so the rate falls away rather than sticking at 30
+	if fps := fc.fpsAt(start.Add(4 * fpsWindow)); fps != 0 {
+		t.Errorf("got %f fps after reads stalled, want 0", fps)
+	}
+}
@@ -0,0 +1,14 @@
+package frame
+
+// 4x4 ordered dithering matrix
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// Returns the dither threshold, in the range [0, 1), for the given position
+func ditherThreshold(x int, y int) float64 {
+	return (bayer4[y&3][x&3] + 0.5) / 16
+}
@@ -0,0 +1,105 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDitherThresholdTemporal(t *testing.T) {
+	for frame := 0; frame < 16; frame++ {
+		for x := 0; x < 8; x++ {
+			cur := ditherThreshold(x, frame)
+			next := ditherThreshold(x, frame+1)
+			if cur == next {
+				t.Errorf("threshold at x %d is %f for frames %d and %d", x, cur, frame, frame+1)
+			}
+			if cur < 0 || cur >= 1 {
+				t.Errorf("threshold at x %d, frame %d is %f, outside [0, 1)", x, frame, cur)
+			}
+		}
+	}
+}
+
+func TestDitherWithinOneLSB(t *testing.T) {
+	for _, v := range []float64{0, 0.2, 17.5, 128.99, 254.7, 255} {
+		c := rgbaf{v, v, v, 255}
+		plain := c.quantize(0)
+		for frame := 0; frame < 4; frame++ {
+			for x := 0; x < 4; x++ {
+				got := c.quantize(ditherThreshold(x, frame))
+				if d := int(got.R) - int(plain.R); d < 0 || d > 1 {
+					t.Errorf("dithering %f moved the channel from %d to %d", v, plain.R, got.R)
+				}
+				if got.A != 255 {
+					t.Errorf("dithering changed opaque alpha to %d", got.A)
+				}
+			}
+		}
+	}
+	// sanity check the undithered path still truncates like the old mix
+	if got := (rgbaf{10.9, 0, 0, 255}).quantize(0); got != (color.RGBA{10, 0, 0, 255}) {
+		t.Errorf("quantize(0) = %v, want truncation", got)
+	}
+}
+
+func TestDitherSpatial(t *testing.T) {
+	grey := func(v uint8) *color.RGBA { return &color.RGBA{R: v, G: v, B: v, A: 255} }
+	newGradient := func(dither bool) *LinearGradient {
+		return &LinearGradient{
+			Rect:    image.Rect(0, 0, 64, 8),
+			Palette: []*color.RGBA{grey(100), grey(102), grey(100)},
+			Dither:  dither,
+		}
+	}
+	plain := newGradient(false).FrameAt(0)
+	dithered := newGradient(true).FrameAt(0)
+	// the pattern stays fixed to the frame's pixels inside an ROI
+	roi := newGradient(true)
+	roi.ROI = image.Rect(0, 2, 64, 6)
+	roi.Fill = color.RGBA{A: 255}
+	framed := roi.FrameAt(0)
+	varied := false
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 8; y++ {
+			p, d := plain.RGBAAt(x, y), dithered.RGBAAt(x, y)
+			if diff := int(d.R) - int(p.R); diff < 0 || diff > 1 {
+				t.Errorf("dithering moved (%d, %d) from %d to %d", x, y, p.R, d.R)
+			}
+			if d != dithered.RGBAAt(x, 0) {
+				varied = true
+			}
+			if p != plain.RGBAAt(x, 0) {
+				t.Errorf("undithered column %d changes at row %d", x, y)
+			}
+			if d != dithered.RGBAAt(x, y%4) {
+				t.Errorf("pattern at (%d, %d) doesn't repeat every 4 rows", x, y)
+			}
+			want := color.RGBA{A: 255}
+			if y >= 2 && y < 6 {
+				want = dithered.RGBAAt(x, y)
+			}
+			if got := framed.RGBAAt(x, y); got != want {
+				t.Errorf("with an ROI (%d, %d) is %v, want %v", x, y, got, want)
+			}
+		}
+	}
+	if !varied {
+		t.Error("dithered columns are the same all the way down")
+	}
+	// averaged over the pattern the columns step through more shades than 100, 101, 102
+	shades := func(img *image.RGBA) int {
+		seen := map[int]bool{}
+		for x := 0; x < 64; x++ {
+			sum := 0
+			for y := 0; y < 4; y++ {
+				sum += int(img.RGBAAt(x, y).R)
+			}
+			seen[sum] = true
+		}
+		return len(seen)
+	}
+	if p, d := shades(plain), shades(dithered); d <= p {
+		t.Errorf("dithering shows %d shades, no more than the %d without", d, p)
+	}
+}
@@ -0,0 +1,58 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrEasing = errors.New("unknown easing")
+
+// Maps how far through a transition a frame is, in [0, 1], to how far its colors
+// have moved, also in [0, 1]
+type Easing func(t float64) float64
+
+func Linear(t float64) float64 {
+	return t
+}
+
+// Starts slow and speeds up
+func EaseInQuad(t float64) float64 {
+	return t * t
+}
+
+// Starts fast and slows down
+func EaseOutQuad(t float64) float64 {
+	return t * (2 - t)
+}
+
+// Starts and ends slow
+func EaseInOutCubic(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	return 1 - math.Pow(-2*t+2, 3)/2
+}
+
+// Easings by the names used on the command line
+var Easings = map[string]Easing{
+	"linear":       Linear,
+	"in-quad":      EaseInQuad,
+	"out-quad":     EaseOutQuad,
+	"in-out-cubic": EaseInOutCubic,
+}
+
+func ParseEasing(s string) (Easing, error) {
+	if e, ok := Easings[s]; ok {
+		return e, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrEasing, s)
+}
+
+// Eases t, linearly when the easing is nil
+func (e Easing) at(t float64) float64 {
+	if e == nil {
+		return t
+	}
+	return e(t)
+}
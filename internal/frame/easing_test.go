@@ -0,0 +1,69 @@
+package frame
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestEasings(t *testing.T) {
+	for name, e := range Easings {
+		if e(0) != 0 || math.Abs(e(1)-1) > 1e-12 {
+			t.Errorf("%s: got %g at 0 and %g at 1, want 0 and 1", name, e(0), e(1))
+		}
+	}
+	if got := EaseInOutCubic(0.5); math.Abs(got-0.5) > 1e-12 {
+		t.Errorf("EaseInOutCubic(0.5) = %g, want 0.5", got)
+	}
+	if EaseInQuad(0.5) >= 0.5 || EaseOutQuad(0.5) <= 0.5 {
+		t.Errorf("quadratic easings at 0.5 are %g in and %g out", EaseInQuad(0.5), EaseOutQuad(0.5))
+	}
+	if _, err := ParseEasing("bounce"); !errors.Is(err, ErrEasing) {
+		t.Errorf("got %v, want %v", err, ErrEasing)
+	}
+}
+
+func TestLinearGradientTransitionEasing(t *testing.T) {
+	a := color.RGBA{0, 0, 0, 255}
+	b := color.RGBA{200, 200, 200, 255}
+	for name, e := range Easings {
+		got := readTransitionColors(t, &LinearGradientTransition{
+			ColorChannel: colorChannel(a, b),
+			Transition:   4,
+			Easing:       e,
+		})
+		want := uint8(200 * e(0.5))
+		if len(got) != 4 || got[2].R != want {
+			t.Errorf("%s: got frames %v, want the middle one %d", name, got, want)
+		}
+	}
+}
+
+func TestLinearGradientEasing(t *testing.T) {
+	width := 8
+	frames := map[string][]byte{}
+	for _, name := range []string{"linear", "in-quad"} {
+		lg := &LinearGradient{
+			ColorChannel: colorChannel(testColors...),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, width, 1),
+			Easing:       Easings[name],
+		}
+		lg.Reset()
+		go lg.Run()
+		frames[name] = readInChunks(t, lg, 4096)
+	}
+	frame := func(name string, i int) color.RGBA {
+		f := frames[name][i*width*4:]
+		return color.RGBA{f[0], f[1], f[2], f[3]}
+	}
+	// a quarter of the way through the quadratic ease has barely moved
+	if frame("in-quad", 1) != frame("in-quad", 0) {
+		t.Errorf("eased scroll moved to %v on its first frame", frame("in-quad", 1))
+	}
+	if frame("linear", 1) == frame("linear", 0) {
+		t.Error("linear scroll didn't move on its first frame")
+	}
+}
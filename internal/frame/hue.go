@@ -0,0 +1,22 @@
+package frame
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/broganross/color-run/internal/colorspace"
+)
+
+// Rotates the hue of c by degrees, keeping its saturation, lightness and alpha
+func rotateHue(c *color.RGBA, degrees float64) *color.RGBA {
+	if degrees == 0 {
+		return c
+	}
+	h, s, l := colorspace.ToHSL(c)
+	return colorspace.FromHSL(h+degrees, s, l, c.A)
+}
+
+// Degrees the hue has turned by at the frame, wrapped so long runs don't lose precision
+func hueAt(perFrame float64, frameIdx int) float64 {
+	return math.Mod(perFrame*float64(frameIdx), 360)
+}
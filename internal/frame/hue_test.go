@@ -0,0 +1,34 @@
+package frame
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/broganross/color-run/internal/colorspace"
+)
+
+func TestHueRotate(t *testing.T) {
+	c := color.RGBA{200, 60, 60, 255}
+	h0, s0, l0 := colorspace.ToHSL(&c)
+	got := readTransitionColors(t, &LinearGradientTransition{
+		ColorChannel: colorChannel(c, c, c),
+		Transition:   20,
+		HueRotate:    25,
+	})
+	for _, f := range []int{1, 7, 19, 30} {
+		h, s, l := colorspace.ToHSL(&got[f])
+		want := math.Mod(h0+25*float64(f), 360)
+		// 8 bit channels put the hue out by up to a degree or so
+		d := math.Abs(h - want)
+		if d > 180 {
+			d = 360 - d
+		}
+		if d > 1.5 {
+			t.Errorf("frame %d hue is %.1f, want %.1f", f, h, want)
+		}
+		if math.Abs(s-s0) > 0.02 || math.Abs(l-l0) > 0.01 {
+			t.Errorf("frame %d saturation and lightness are %.3f, %.3f, want %.3f, %.3f", f, s, l, s0, l0)
+		}
+	}
+}
@@ -1,21 +1,66 @@
 package frame
 
 import (
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"io"
+	"math"
+	"math/rand"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 )
 
 // Creates frames which show a gradient sliding to the left
 type LinearGradient struct {
+	stopper
 	ColorChannel chan *color.RGBA
 	imageChannel chan *image.RGBA
 	Transition   int
 	Rect         image.Rectangle
-	img          *image.RGBA
-	idx          int
+	// Fixed colors used by FrameAt and StopColors
+	Palette []*color.RGBA
+	// Stop (0 left, 1 middle, 2 right) to the index of the Palette color always shown
+	// there in place of the streamed color
+	StopColors map[int]int
+	// Shift the dither pattern every frame so the eye averages out banding
+	TemporalDither bool
+	// Dither with a pattern fixed to each pixel, which hides banding without flicker.
+	// Renders four rows per frame rather than one.
+	Dither bool
+	// Encode colors premultiplied by their alpha
+	Premultiply bool
+	// Color space the gradient is interpolated in, sRGB when empty
+	MixSpace MixSpace
+	// Interpolate along a curve through the stops so the gradient has no kink at the
+	// middle stop, rather than mixing each pair of stops independently
+	Smooth bool
+	// Degrees the hue of every color is turned by each frame, wrapping at 360
+	HueRotate float64
+	// Largest amount of noise added to each channel, in 8 bit levels.  Rows repeat, so
+	// the grain runs in columns which change every frame.
+	Grain float64
+	// Source of the grain, seed it for reproducible frames.  Required when Grain is set.
+	Rand *rand.Rand
+	// When set the gradient is only rendered inside this part of Rect and the rest of
+	// the frame is Fill
+	ROI  image.Rectangle
+	Fill color.RGBA
+	// Direction the gradient runs in, in degrees clockwise from left to right.  0
+	// scrolls horizontally and 90 runs top to bottom.  Any other direction renders
+	// every pixel of every frame rather than repeating one row, so costs more.
+	Angle float64
+	// Paces the scroll from one color to the next, linear when nil
+	Easing Easing
+	// Pixels the gradient moves each frame, which may be a fraction, in place of
+	// taking Transition frames to move a whole width.  Capped at the width.
+	Speed float64
+	img   *image.RGBA
+	idx   int
+	// frames Read has finished with, for Run to render the next ones into
+	frames sync.Pool
 }
 
 func (lgis *LinearGradient) Read(out []byte) (int, error) {
@@ -28,21 +73,23 @@ func (lgis *LinearGradient) Read(out []byte) (int, error) {
 			img, ok := <-lgis.imageChannel
 			if !ok {
 				end = true
+				break
 			}
 			lgis.img = img
 		}
-		n := 0
-		for i, j := lgis.idx, cnt; i < imageSize && j < l; i, j = i+4, j+4 {
-			x := i % lgis.img.Stride
-			out[j] = lgis.img.Pix[x]
-			out[j+1] = lgis.img.Pix[x+1]
-			out[j+2] = lgis.img.Pix[x+2]
-			out[j+3] = lgis.img.Pix[x+3]
-			n += 4
-		}
-		lgis.idx += n
-		cnt += n
+		// the rendered image holds fewer rows than the frame, so idx counts through the
+		// whole frame and each of its rows is looked up.  Copying up to the end of the
+		// row means a buffer that isn't a multiple of 4 ends mid pixel and the next call
+		// carries on from there.
+		rowSize := lgis.Rect.Dx() * 4
+		for lgis.idx < imageSize && cnt < l {
+			row := lgis.rowPix(lgis.img, lgis.idx/rowSize)
+			n := copy(out[cnt:], row[lgis.idx%rowSize:])
+			lgis.idx += n
+			cnt += n
+		}
 		if lgis.idx >= imageSize {
+			lgis.frames.Put(lgis.img)
 			lgis.img = nil
 			lgis.idx = 0
 		}
@@ -55,26 +102,56 @@ func (lgis *LinearGradient) Read(out []byte) (int, error) {
 	return cnt, err
 }
 
+// Clears any state left over from a previous Run and allocates the frame buffer
+// for the next one, so Read may be called as soon as Run is started.  It must only
+// be called once the previous Run has returned and Read has hit EOF, and must be
+// called between runs of the same instance.
+func (lgis *LinearGradient) Reset() {
+	lgis.img = nil
+	lgis.idx = 0
+	lgis.imageChannel = make(chan *image.RGBA, lgis.bufferSize())
+	lgis.reset()
+}
+
 func (lgis *LinearGradient) Run() {
-	lgis.imageChannel = make(chan *image.RGBA, lgis.Transition*3)
+	if lgis.imageChannel == nil {
+		lgis.imageChannel = make(chan *image.RGBA, lgis.bufferSize())
+	}
+	if lgis.Transition < 1 && lgis.Speed <= 0 {
+		log.Error().Err(fmt.Errorf("%w: %d frames", ErrTransition, lgis.Transition)).Msg("not rendering gradient")
+		close(lgis.imageChannel)
+		return
+	}
 	var left *color.RGBA
 	var middle *color.RGBA
 	var right *color.RGBA
-	step := lgis.Rect.Dx() / lgis.Transition
+	width, axis := lgis.axis()
+	fill := lgis.fillRows()
+	// the rows are sent as the frame unless render copies them into one, in which
+	// case they're rendered into the same image every frame
+	var rows *image.RGBA
+	if fill != nil || axis != nil {
+		rows = image.NewRGBA(image.Rect(0, 0, width, lgis.rows()))
+	}
+	// frames into the scroll from one color to the next, or pixels when Speed is set
+	segmentFrame := 0
+	var offset float64
+	speed := min(lgis.Speed, float64(width))
 	done := false
+	stopped := lgis.stopped()
 	getCol := func() *color.RGBA {
-		i, ok := <-lgis.ColorChannel
-		if !ok {
+		select {
+		case i, ok := <-lgis.ColorChannel:
+			if !ok {
+				done = true
+			}
+			return i
+		case <-stopped:
 			done = true
+			return nil
 		}
-		return i
 	}
-	stops := [3]int{
-		0,
-		lgis.Rect.Dx(),
-		lgis.Rect.Dx() * 2,
-	}
-	for !done {
+	for frameIdx := 0; !done; frameIdx++ {
 		if left == nil {
 			left = getCol()
 		}
@@ -84,34 +161,283 @@ func (lgis *LinearGradient) Run() {
 		if right == nil {
 			right = getCol()
 		}
-		img := image.NewRGBA(image.Rect(0, 0, lgis.Rect.Dx(), 1))
-		for x := 0; x < lgis.Rect.Dx(); x++ {
-			col := mix(left, middle, lerp(stops[0], stops[1], x))
-			col = mix(col, right, lerp(stops[1], stops[2], x))
-			img.SetRGBA(x, 0, *col)
-		}
-		lgis.imageChannel <- img
-		stops[0] -= step
-		stops[1] -= step
-		stops[2] -= step
-		if stops[1] <= 0 {
+		if done || !lgis.wait() {
+			break
+		}
+		// the shift is worked out from the start of the segment rather than accumulated
+		// so widths which don't divide by the transition don't drift.  It steps whole
+		// pixels unless the transition is longer than the width, when it would stall
+		// for several frames at a time, so then it's kept in fractions of a pixel.
+		w := float64(width)
+		pos := float64(segmentFrame) / float64(lgis.Transition)
+		if speed > 0 {
+			pos = offset / w
+		}
+		if lgis.Easing != nil {
+			pos = lgis.Easing(pos)
+		}
+		shift := pos * w
+		if speed <= 0 && lgis.Transition <= width {
+			shift = math.Trunc(shift)
+		}
+		stops := [3]float64{-shift, w - shift, 2*w - shift}
+		l, m, r := lgis.pinStops(left, middle, right)
+		if lgis.HueRotate != 0 {
+			deg := hueAt(lgis.HueRotate, frameIdx)
+			l, m, r = rotateHue(l, deg), rotateHue(m, deg), rotateHue(r, deg)
+		}
+		dst := rows
+		if dst == nil {
+			dst = lgis.frame(width, lgis.rows())
+		}
+		img := lgis.render(lgis.rowInto(dst, l, m, r, stops, frameIdx), fill, axis)
+		select {
+		case lgis.imageChannel <- img:
+		case <-stopped:
+			done = true
+		}
+		next := false
+		if speed > 0 {
+			offset += speed
+			if next = offset >= w; next {
+				offset -= w
+			}
+		} else {
+			segmentFrame++
+			if next = segmentFrame >= lgis.Transition; next {
+				segmentFrame = 0
+			}
+		}
+		if next {
 			left = middle
 			middle = right
 			right = nil
-			stops[0] = stops[1]
-			stops[1] = stops[2]
-			stops[2] = stops[1] + lgis.Rect.Dx()
 		}
 	}
 	close(lgis.imageChannel)
 }
 
-// Creates frames that transition from one color to another
+// Replaces the colors of the stops in StopColors.  Indexes outside the palette are ignored.
+func (lgis *LinearGradient) pinStops(left *color.RGBA, middle *color.RGBA, right *color.RGBA) (*color.RGBA, *color.RGBA, *color.RGBA) {
+	cols := [3]*color.RGBA{left, middle, right}
+	for stop, idx := range lgis.StopColors {
+		if stop >= 0 && stop < len(cols) && idx >= 0 && idx < len(lgis.Palette) {
+			cols[stop] = lgis.Palette[idx]
+		}
+	}
+	return cols[0], cols[1], cols[2]
+}
+
+// Part of Rect the gradient is rendered in, all of it unless ROI overlaps it
+func (lgis *LinearGradient) gradientRect() image.Rectangle {
+	roi := lgis.ROI.Intersect(lgis.Rect)
+	if roi.Empty() {
+		return lgis.Rect
+	}
+	return roi
+}
+
+// Returns the rows a frame is made of before rendering the gradient into them.  Without
+// an ROI that's nothing.  With one, the first row is for the rows crossing the ROI and
+// the second for the rest, both Fill.  render repeats the first for each gradient row.
+func (lgis *LinearGradient) fillRows() []byte {
+	if lgis.gradientRect() == lgis.Rect {
+		return nil
+	}
+	width := lgis.Rect.Dx()
+	pix := make([]byte, 2*width*4)
+	for i := 0; i < len(pix); i += 4 {
+		pix[i], pix[i+1], pix[i+2], pix[i+3] = lgis.Fill.R, lgis.Fill.G, lgis.Fill.B, lgis.Fill.A
+	}
+	return pix
+}
+
+// Places the gradient row into a copy of the fill rows.  At an angle the whole frame
+// is rendered, each pixel of the gradient taking its color from its place on the axis.
+func (lgis *LinearGradient) render(row *image.RGBA, fill []byte, axis []int) *image.RGBA {
+	if axis != nil {
+		return lgis.renderAngled(row, fill, axis)
+	}
+	if fill == nil {
+		return row
+	}
+	// the gradient rows followed by the fill row for the rest of the frame
+	rows := row.Rect.Dy()
+	img := lgis.frame(lgis.Rect.Dx(), rows+1)
+	offset := (lgis.gradientRect().Min.X - lgis.Rect.Min.X) * 4
+	for y := 0; y < rows; y++ {
+		copy(img.Pix[y*img.Stride:], fill[:img.Stride])
+		copy(img.Pix[y*img.Stride+offset:], row.Pix[y*row.Stride:(y+1)*row.Stride])
+	}
+	copy(img.Pix[rows*img.Stride:], fill[img.Stride:])
+	return img
+}
+
+// Returns a width by height image for a frame, reusing one Read has finished with when
+// there is one.  Its pixels are left as they were, the caller overwrites them all.
+func (lgis *LinearGradient) frame(width int, height int) *image.RGBA {
+	if img, ok := lgis.frames.Get().(*image.RGBA); ok && img.Rect.Dx() == width && img.Rect.Dy() == height {
+		return img
+	}
+	return image.NewRGBA(image.Rect(0, 0, width, height))
+}
+
+// Number of distinct rows the gradient is rendered with
+func (lgis *LinearGradient) rows() int {
+	if lgis.Dither {
+		return 4
+	}
+	return 1
+}
+
+// Returns the pixels of row y of the frame from the rendered rows.  Only the distinct
+// rows are rendered, so the frame's rows are picked out of them here, each sliced by
+// the image's Stride to just the width of the frame.
+func (lgis *LinearGradient) rowPix(img *image.RGBA, y int) []byte {
+	if lgis.angled() {
+		return img.Pix[y*img.Stride : y*img.Stride+lgis.Rect.Dx()*4]
+	}
+	rows := lgis.rows()
+	r := y % rows
+	if img.Rect.Dy() > rows {
+		roi := lgis.gradientRect()
+		if y += lgis.Rect.Min.Y; y < roi.Min.Y || y >= roi.Max.Y {
+			r = rows
+		}
+	}
+	return img.Pix[r*img.Stride : r*img.Stride+lgis.Rect.Dx()*4]
+}
+
+// Renders the rows of the gradient with the colors placed at the given stops.  There's
+// one unless Dither needs a row for each row of its pattern.
+func (lgis *LinearGradient) row(left *color.RGBA, middle *color.RGBA, right *color.RGBA, stops [3]float64, frameIdx int, width int) *image.RGBA {
+	return lgis.rowInto(image.NewRGBA(image.Rect(0, 0, width, lgis.rows())), left, middle, right, stops, frameIdx)
+}
+
+// Renders the rows into img, which is as wide as the gradient and has lgis.rows() rows
+func (lgis *LinearGradient) rowInto(img *image.RGBA, left *color.RGBA, middle *color.RGBA, right *color.RGBA, stops [3]float64, frameIdx int) *image.RGBA {
+	width := img.Rect.Dx()
+	l, m, r := toRGBAf(left), toRGBAf(middle), toRGBAf(right)
+	var tl, tm, tr rgbaf
+	if lgis.Smooth {
+		// Catmull-Rom style tangents, the end stops have one neighbour so use it twice
+		tl = lgis.MixSpace.tangent(l, m, 1)
+		tm = lgis.MixSpace.tangent(l, r, 0.5)
+		tr = lgis.MixSpace.tangent(m, r, 1)
+	}
+	for x := 0; x < width; x++ {
+		var col rgbaf
+		switch {
+		case !lgis.Smooth:
+			col = lgis.MixSpace.mix(l, m, lerp(stops[0], stops[1], x))
+			col = lgis.MixSpace.mix(col, r, lerp(stops[1], stops[2], x))
+		case float64(x) < stops[1]:
+			col = lgis.MixSpace.hermite(l, m, tl, tm, lerp(stops[0], stops[1], x))
+		default:
+			col = lgis.MixSpace.hermite(m, r, tm, tr, lerp(stops[1], stops[2], x))
+		}
+		if lgis.Grain > 0 {
+			for i := 0; i < 3; i++ {
+				col[i] += (lgis.Rand.Float64()*2 - 1) * lgis.Grain
+			}
+		}
+		if lgis.Premultiply {
+			col = col.premultiply()
+		}
+		for y := 0; y < img.Rect.Dy(); y++ {
+			var threshold float64
+			if lgis.Dither || lgis.TemporalDither {
+				// the spatial pattern runs down the rows, the temporal one through the frames
+				py := 0
+				if lgis.Dither {
+					py = y
+				}
+				if lgis.TemporalDither {
+					py += frameIdx
+				}
+				threshold = ditherThreshold(x, py)
+			}
+			// straight into Pix, SetRGBA's bounds check is a lot per pixel at 1080p
+			c := col.quantize(threshold)
+			i := y*img.Stride + x*4
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+	}
+	return img
+}
+
+// Renders the full frame the gradient would show after scrolling offset pixels
+// through Palette, without touching the state used by Run.  The palette repeats,
+// so offsets wrap every len(Palette) pixels times the gradient's width.  Returns
+// nil when there is no palette.
+func (lgis *LinearGradient) FrameAt(offset int) *image.RGBA {
+	n := len(lgis.Palette)
+	width, axis := lgis.axis()
+	if n == 0 || width <= 0 {
+		return nil
+	}
+	period := n * width
+	offset = (offset%period + period) % period
+	k := offset / width
+	shift := offset % width
+	w := float64(width)
+	stops := [3]float64{float64(-shift), w - float64(shift), 2*w - float64(shift)}
+	l, m, r := lgis.pinStops(lgis.Palette[k], lgis.Palette[(k+1)%n], lgis.Palette[(k+2)%n])
+	rows := lgis.render(lgis.row(l, m, r, stops, 0, width), lgis.fillRows(), axis)
+	img := image.NewRGBA(image.Rect(0, 0, lgis.Rect.Dx(), lgis.Rect.Dy()))
+	for y := 0; y < lgis.Rect.Dy(); y++ {
+		copy(img.Pix[y*img.Stride:], lgis.rowPix(rows, y))
+	}
+	return img
+}
+
+// How consecutive colors are paired up into transitions
+type PairingMode string
+
+const (
+	// Every color ends one transition and starts the next (A→B, B→C)
+	PairingOverlap PairingMode = "overlap"
+	// Colors are consumed as separate pairs (A→B, C→D)
+	PairingDiscrete PairingMode = "discrete"
+)
+
+var ErrPairingMode = errors.New("unknown pairing mode")
+
+var ErrTransition = errors.New("transition must be at least 1 frame")
+
+func ParsePairingMode(s string) (PairingMode, error) {
+	switch mode := PairingMode(s); mode {
+	case PairingOverlap, PairingDiscrete:
+		return mode, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrPairingMode, s)
+}
+
+// Creates frames of one solid color fading from one color to another.  Only the color
+// of each frame is sent to Read, which repeats it over the whole frame.
 type LinearGradientTransition struct {
+	stopper
 	ColorChannel chan *color.RGBA
 	Transition   int
-	ImageWidth   int
-	ImageHeight  int
+	Pairing      PairingMode
+	// Number of frames each color is held for before transitioning to the next
+	DwellFrames int
+	ImageWidth  int
+	ImageHeight int
+	// Shift the dither threshold every frame so the eye averages out banding
+	TemporalDither bool
+	// Encode colors premultiplied by their alpha
+	Premultiply bool
+	// Color space colors are faded in, sRGB when empty
+	MixSpace MixSpace
+	// Largest change of any channel between consecutive frames, in 8 bit levels.
+	// Transitions which would jump further are given more frames.  The cut between
+	// discrete pairs isn't a transition so isn't limited.  0 disables it.
+	MaxDelta int
+	// Degrees the hue of every color is turned by each frame, wrapping at 360
+	HueRotate float64
+	// Paces each fade, linear when nil
+	Easing       Easing
 	col          *color.RGBA
 	idx          int
 	imageChannel chan *color.RGBA
@@ -126,20 +452,17 @@ func (lgt *LinearGradientTransition) Read(out []byte) (int, error) {
 			col, ok := <-lgt.imageChannel
 			if !ok {
 				end = true
+				break
 			}
 			lgt.col = col
 		}
-		n := 0
 		imageSize := lgt.ImageWidth * lgt.ImageHeight * 4
-		for i, j := lgt.idx, cnt; i < imageSize && j < l; i, j = i+4, j+4 {
-			out[j] = lgt.col.R
-			out[j+1] = lgt.col.B
-			out[j+2] = lgt.col.B
-			out[j+3] = lgt.col.A
-			n += 4
-		}
-		lgt.idx += n
-		cnt += n
+		px := [4]uint8{lgt.col.R, lgt.col.G, lgt.col.B, lgt.col.A}
+		// written byte wise so a buffer that isn't a multiple of 4 ends mid pixel
+		// and the next call carries on from there
+		for ; lgt.idx < imageSize && cnt < l; lgt.idx, cnt = lgt.idx+1, cnt+1 {
+			out[cnt] = px[lgt.idx&3]
+		}
 		if lgt.idx >= imageSize {
 			lgt.col = nil
 			lgt.idx = 0
@@ -152,48 +475,129 @@ func (lgt *LinearGradientTransition) Read(out []byte) (int, error) {
 	return cnt, err
 }
 
-func (lgt *LinearGradientTransition) Run() {
+// Clears any state left over from a previous Run and allocates the frame buffer
+// for the next one, so Read may be called as soon as Run is started.  It must only
+// be called once the previous Run has returned and Read has hit EOF, and must be
+// called between runs of the same instance.
+func (lgt *LinearGradientTransition) Reset() {
+	lgt.col = nil
+	lgt.idx = 0
 	lgt.imageChannel = make(chan *color.RGBA, lgt.Transition*3)
+	lgt.reset()
+}
+
+func (lgt *LinearGradientTransition) Run() {
+	if lgt.imageChannel == nil {
+		lgt.imageChannel = make(chan *color.RGBA, lgt.Transition*3)
+	}
 	var left *color.RGBA
 	var right *color.RGBA
 	done := false
-	for !done {
-		if left == nil {
-			l, ok := <-lgt.ColorChannel
+	stopped := lgt.stopped()
+	getCol := func() *color.RGBA {
+		select {
+		case c, ok := <-lgt.ColorChannel:
 			if !ok {
 				done = true
 			}
-			left = l
+			return c
+		case <-stopped:
+			done = true
+			return nil
+		}
+	}
+	send := func(c rgbaf, threshold float64) {
+		if !lgt.wait() {
+			done = true
+			return
+		}
+		if lgt.Premultiply {
+			c = c.premultiply()
+		}
+		col := c.quantize(threshold)
+		select {
+		case lgt.imageChannel <- &col:
+		case <-stopped:
+			done = true
+		}
+	}
+	frameIdx := 0
+	for !done {
+		if left == nil {
+			left = getCol()
 		}
 		if right == nil {
-			r, ok := <-lgt.ColorChannel
-			if !ok {
-				done = true
-			}
-			right = r
+			right = getCol()
+		}
+		if done {
+			break
 		}
 		log.Debug().Msg("got left and right")
-		for frame := 0; frame < lgt.Transition; frame++ {
-			ratio := float32(frame) / float32(lgt.Transition)
-			color := mix(left, right, ratio)
-			lgt.imageChannel <- color
-			// img := image.NewRGBA(image.Rect(0, 0, lgt.ImageWidth, lgt.ImageHeight))
-			// for x := 0; x < lgt.ImageWidth; x++ {
-			// 	for y := 0; y < lgt.ImageHeight; y++ {
-			// 		img.Set(x, y, color)
-			// 	}
-			// }
-			// lgt.imageChannel <- img
-		}
-		left = right
+		for frame := 0; frame < lgt.DwellFrames && !done; frame++ {
+			send(toRGBAf(rotateHue(left, hueAt(lgt.HueRotate, frameIdx))), 0)
+			frameIdx++
+		}
+		// the first transition frame is left itself, which the dwell already covered
+		start := 0
+		if lgt.DwellFrames > 0 {
+			start = 1
+		}
+		frames := lgt.transitionFrames(left, right)
+		for frame := start; frame < frames && !done; frame++ {
+			ratio := lgt.Easing.at(float64(frame) / float64(frames))
+			var threshold float64
+			if lgt.TemporalDither {
+				threshold = ditherThreshold(frameIdx, frameIdx>>2)
+			}
+			deg := hueAt(lgt.HueRotate, frameIdx)
+			send(lgt.MixSpace.mix(toRGBAf(rotateHue(left, deg)), toRGBAf(rotateHue(right, deg)), ratio), threshold)
+			frameIdx++
+		}
+		if lgt.Pairing == PairingDiscrete {
+			left = nil
+		} else {
+			left = right
+		}
 		right = nil
 	}
 	close(lgt.imageChannel)
 }
 
+// Number of frames the transition from left to right takes, stretched until no
+// channel changes by more than MaxDelta from one frame to the next
+func (lgt *LinearGradientTransition) transitionFrames(left *color.RGBA, right *color.RGBA) int {
+	frames := lgt.Transition
+	if lgt.MaxDelta <= 0 {
+		return frames
+	}
+	l, r := toRGBAf(left), toRGBAf(right)
+	for {
+		step := lgt.largestStep(l, r, frames)
+		if step <= float64(lgt.MaxDelta) {
+			return frames
+		}
+		// steps shrink roughly in proportion to the frames outside of sRGB
+		frames = max(frames+1, int(math.Ceil(float64(frames)*step/float64(lgt.MaxDelta))))
+	}
+}
+
+// Largest change of any channel between consecutive frames of a transition
+func (lgt *LinearGradientTransition) largestStep(l rgbaf, r rgbaf, frames int) float64 {
+	largest := 0.0
+	prev := l
+	for frame := 1; frame <= frames; frame++ {
+		c := lgt.MixSpace.mix(l, r, lgt.Easing.at(float64(frame)/float64(frames)))
+		for i := range c {
+			largest = max(largest, math.Abs(c[i]-prev[i]))
+		}
+		prev = c
+	}
+	return largest
+}
+
 // Linear interpolation
-func lerp(min int, max int, pos int) float32 {
-	v := float32(pos-min) / float32(max-min)
+func lerp(min float64, max float64, pos int) float64 {
+	v := (float64(pos) - min) / (max - min)
 	if v > 1.0 {
 		v = 1.0
 	}
@@ -203,11 +607,8 @@ func lerp(min int, max int, pos int) float32 {
 	return v
 }
 
-// mix two colors
-func mix(c1 *color.RGBA, c2 *color.RGBA, ratio float32) *color.RGBA {
-	r := uint8(float32(c1.R)*(1.0-ratio) + float32(c2.R)*ratio)
-	g := uint8(float32(c1.G)*(1.0-ratio) + float32(c2.G)*ratio)
-	b := uint8(float32(c1.B)*(1.0-ratio) + float32(c2.B)*ratio)
-	a := uint8(float32(c1.A)*(1.0-ratio) + float32(c2.A)*ratio)
-	return &color.RGBA{r, g, b, a}
+// mix two colors.  The math is done in float64 so subtle ramps don't pick up rounding artifacts.
+func mix(c1 *color.RGBA, c2 *color.RGBA, ratio float64) *color.RGBA {
+	col := mixf(toRGBAf(c1), toRGBAf(c2), ratio).quantize(0)
+	return &col
 }
@@ -0,0 +1,822 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Returns a closed channel filled with the given colors
+func colorChannel(cols ...color.RGBA) chan *color.RGBA {
+	ch := make(chan *color.RGBA, len(cols))
+	for i := range cols {
+		ch <- &cols[i]
+	}
+	close(ch)
+	return ch
+}
+
+var testColors = []color.RGBA{
+	{255, 0, 0, 255},
+	{0, 255, 0, 255},
+	{0, 0, 255, 255},
+	{255, 255, 0, 255},
+	{0, 255, 255, 255},
+}
+
+func TestLinearGradientReset(t *testing.T) {
+	lg := &LinearGradient{
+		ColorChannel: colorChannel(testColors...),
+		Transition:   4,
+		Rect:         image.Rect(0, 0, 8, 2),
+	}
+	lg.Reset()
+	go lg.Run()
+	first, err := io.ReadAll(lg)
+	if err != nil {
+		t.Fatalf("reading first run: %s", err)
+	}
+	if len(first) == 0 {
+		t.Fatal("first run produced no frames")
+	}
+	if !bytes.Equal(first[:4], []byte{255, 0, 0, 255}) {
+		t.Errorf("first pixel %v, want the first color", first[:4])
+	}
+
+	lg.ColorChannel = colorChannel(testColors...)
+	lg.Reset()
+	go lg.Run()
+	second, err := io.ReadAll(lg)
+	if err != nil {
+		t.Fatalf("reading second run: %s", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("second run after Reset differs from the first")
+	}
+}
+
+func TestLinearGradientTransitionReset(t *testing.T) {
+	lgt := &LinearGradientTransition{
+		ColorChannel: colorChannel(testColors...),
+		Transition:   3,
+		ImageWidth:   2,
+		ImageHeight:  2,
+	}
+	lgt.Reset()
+	go lgt.Run()
+	first, err := io.ReadAll(lgt)
+	if err != nil {
+		t.Fatalf("reading first run: %s", err)
+	}
+	if len(first) == 0 {
+		t.Fatal("first run produced no frames")
+	}
+
+	lgt.ColorChannel = colorChannel(testColors...)
+	lgt.Reset()
+	go lgt.Run()
+	second, err := io.ReadAll(lgt)
+	if err != nil {
+		t.Fatalf("reading second run: %s", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("second run after Reset differs from the first")
+	}
+}
+
+// Reads every frame of a 1x1 transition as a color
+func readTransitionColors(t *testing.T, lgt *LinearGradientTransition) []color.RGBA {
+	t.Helper()
+	lgt.ImageWidth = 1
+	lgt.ImageHeight = 1
+	lgt.Reset()
+	go lgt.Run()
+	b, err := io.ReadAll(lgt)
+	if err != nil {
+		t.Fatalf("reading frames: %s", err)
+	}
+	out := make([]color.RGBA, 0, len(b)/4)
+	for i := 0; i+4 <= len(b); i += 4 {
+		out = append(out, color.RGBA{b[i], b[i+1], b[i+2], b[i+3]})
+	}
+	return out
+}
+
+func TestLinearGradientTransitionPairing(t *testing.T) {
+	a := color.RGBA{0, 0, 0, 255}
+	b := color.RGBA{100, 100, 100, 255}
+	c := color.RGBA{200, 200, 200, 255}
+	d := color.RGBA{50, 50, 50, 255}
+	tests := []struct {
+		mode PairingMode
+		want []color.RGBA
+	}{
+		{PairingOverlap, []color.RGBA{a, {50, 50, 50, 255}, b, {150, 150, 150, 255}, c, {125, 125, 125, 255}}},
+		{PairingDiscrete, []color.RGBA{a, {50, 50, 50, 255}, c, {125, 125, 125, 255}}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			got := readTransitionColors(t, &LinearGradientTransition{
+				ColorChannel: colorChannel(a, b, c, d),
+				Transition:   2,
+				Pairing:      tt.mode,
+			})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got frames %v, want %v", got, tt.want)
+			}
+			if tt.mode == PairingDiscrete && slices.Contains(got, b) {
+				t.Errorf("discrete pairing reused %v as the start of a transition", b)
+			}
+		})
+	}
+}
+
+func TestParsePairingMode(t *testing.T) {
+	for _, s := range []string{"overlap", "discrete"} {
+		if mode, err := ParsePairingMode(s); err != nil || string(mode) != s {
+			t.Errorf("ParsePairingMode(%q) = %q, %v", s, mode, err)
+		}
+	}
+	if _, err := ParsePairingMode("sideways"); !errors.Is(err, ErrPairingMode) {
+		t.Errorf("unknown mode returned %v, want %v", err, ErrPairingMode)
+	}
+}
+
+func TestLinearGradientTransitionDwell(t *testing.T) {
+	a := color.RGBA{0, 0, 0, 255}
+	b := color.RGBA{100, 100, 100, 255}
+	for _, dwell := range []int{0, 1, 3} {
+		got := readTransitionColors(t, &LinearGradientTransition{
+			ColorChannel: colorChannel(a, b),
+			Transition:   4,
+			DwellFrames:  dwell,
+		})
+		held := 0
+		for held < len(got) && got[held] == a {
+			held++
+		}
+		want := dwell
+		if dwell == 0 {
+			// without a dwell the first transition frame is the starting color
+			want = 1
+		}
+		if held != want {
+			t.Errorf("dwell %d held the color for %d frames, want %d", dwell, held, want)
+		}
+		if held < len(got) && got[held] != (color.RGBA{25, 25, 25, 255}) {
+			t.Errorf("dwell %d: first transition frame is %v, want a quarter of the way to %v", dwell, got[held], b)
+		}
+	}
+}
+
+// Waits for the number of goroutines to drop back to base
+func waitForGoroutines(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines leaked", runtime.NumGoroutine()-base)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Returns a channel with more colors than a test will read which is never closed
+func endlessColors() chan *color.RGBA {
+	ch := make(chan *color.RGBA, 100)
+	for i := 0; i < cap(ch); i++ {
+		ch <- &testColors[i%len(testColors)]
+	}
+	return ch
+}
+
+func TestCloseUnwinds(t *testing.T) {
+	makers := map[string]interface {
+		io.ReadCloser
+		Reset()
+		Run()
+	}{
+		"linear": &LinearGradient{
+			ColorChannel: endlessColors(),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, 8, 2),
+		},
+		"transition": &LinearGradientTransition{
+			ColorChannel: endlessColors(),
+			Transition:   4,
+			ImageWidth:   2,
+			ImageHeight:  2,
+		},
+		"radial": &RadialGradient{
+			ColorChannel: endlessColors(),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, 2, 2),
+		},
+		"multistop": &MultiStopGradient{
+			ColorChannel: endlessColors(),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, 8, 2),
+			Stops:        5,
+		},
+	}
+	for name, fm := range makers {
+		t.Run(name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			fm.Reset()
+			ran := make(chan struct{})
+			go func() {
+				fm.Run()
+				close(ran)
+			}()
+			buf := make([]byte, 16)
+			if _, err := io.ReadFull(fm, buf); err != nil {
+				t.Fatalf("reading a frame: %s", err)
+			}
+			fm.Close()
+			select {
+			case <-ran:
+			case <-time.After(time.Second):
+				t.Fatal("Run did not return after Close")
+			}
+			if _, err := io.ReadAll(fm); err != nil {
+				t.Errorf("draining frames: %s", err)
+			}
+			if _, err := fm.Read(buf); err != io.EOF {
+				t.Errorf("read after Close returned %v, want EOF", err)
+			}
+			waitForGoroutines(t, base)
+		})
+	}
+}
+
+// Reads everything from r using reads of the given size
+func readInChunks(t *testing.T, r io.Reader, size int) []byte {
+	t.Helper()
+	out := []byte{}
+	buf := make([]byte, size)
+	for {
+		n, err := r.Read(buf)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("reading: %s", err)
+		}
+	}
+}
+
+func TestReadOddBufferSizes(t *testing.T) {
+	colors := []color.RGBA{
+		{10, 20, 30, 255},
+		{200, 150, 100, 255},
+		{40, 220, 90, 255},
+		{90, 10, 250, 255},
+	}
+	makers := map[string]func() io.Reader{
+		"linear": func() io.Reader {
+			lg := &LinearGradient{
+				ColorChannel: colorChannel(colors...),
+				Transition:   5,
+				Rect:         image.Rect(0, 0, 33, 7),
+			}
+			lg.Reset()
+			go lg.Run()
+			return lg
+		},
+		"transition": func() io.Reader {
+			lgt := &LinearGradientTransition{
+				ColorChannel: colorChannel(colors...),
+				Transition:   5,
+				ImageWidth:   33,
+				ImageHeight:  7,
+			}
+			lgt.Reset()
+			go lgt.Run()
+			return lgt
+		},
+		"radial": func() io.Reader {
+			rg := &RadialGradient{
+				ColorChannel: colorChannel(colors...),
+				Transition:   5,
+				Rect:         image.Rect(0, 0, 33, 7),
+			}
+			rg.Reset()
+			go rg.Run()
+			return rg
+		},
+		"multistop": func() io.Reader {
+			msg := &MultiStopGradient{
+				ColorChannel: colorChannel(colors...),
+				Transition:   5,
+				Rect:         image.Rect(0, 0, 33, 7),
+				Stops:        2,
+			}
+			msg.Reset()
+			go msg.Run()
+			return msg
+		},
+	}
+	for name, newMaker := range makers {
+		t.Run(name, func(t *testing.T) {
+			want := readInChunks(t, newMaker(), 33*7*4)
+			if len(want) == 0 || len(want)%(33*7*4) != 0 {
+				t.Fatalf("reference read is %d bytes, not whole frames", len(want))
+			}
+			for _, size := range []int{1, 3, 5, 4097} {
+				got := readInChunks(t, newMaker(), size)
+				if !bytes.Equal(got, want) {
+					t.Errorf("reading %d bytes at a time differs from whole frames", size)
+				}
+			}
+		})
+	}
+}
+
+func TestLinearGradientTransitionChannels(t *testing.T) {
+	c := color.RGBA{10, 20, 30, 40}
+	got := readTransitionColors(t, &LinearGradientTransition{
+		ColorChannel: colorChannel(c, c),
+		Transition:   1,
+	})
+	if len(got) != 1 || got[0] != c {
+		t.Errorf("got frames %v, want %v", got, c)
+	}
+}
+
+func TestLinearGradientFrameAt(t *testing.T) {
+	palette := make([]*color.RGBA, len(testColors))
+	for i := range testColors {
+		palette[i] = &testColors[i]
+	}
+	width, height := 12, 3
+	lg := &LinearGradient{
+		ColorChannel: colorChannel(testColors...),
+		Transition:   4,
+		Rect:         image.Rect(0, 0, width, height),
+		Palette:      palette,
+	}
+	lg.Reset()
+	go lg.Run()
+	frames := readInChunks(t, lg, 4096)
+	frameSize := width * height * 4
+	step := width / lg.Transition
+	for i := 0; i < 3; i++ {
+		want := frames[i*frameSize : (i+1)*frameSize]
+		if got := lg.FrameAt(i * step); !bytes.Equal(got.Pix, want) {
+			t.Errorf("FrameAt(%d) differs from frame %d of Run", i*step, i)
+		}
+	}
+	period := len(palette) * width
+	if !bytes.Equal(lg.FrameAt(period).Pix, lg.FrameAt(0).Pix) {
+		t.Error("FrameAt doesn't wrap after the whole palette has scrolled past")
+	}
+	if !bytes.Equal(lg.FrameAt(-width).Pix, lg.FrameAt(period-width).Pix) {
+		t.Error("negative offsets don't wrap")
+	}
+	// scrolling a whole width puts the second color where the first was
+	if got := lg.FrameAt(width).RGBAAt(0, 0); got != testColors[1] {
+		t.Errorf("FrameAt(width) starts with %v, want %v", got, testColors[1])
+	}
+}
+
+func TestLinearGradientUnevenWidth(t *testing.T) {
+	for _, tt := range []struct{ width, transition int }{{10, 3}, {7, 4}, {13, 5}} {
+		lg := &LinearGradient{
+			ColorChannel: colorChannel(testColors...),
+			Transition:   tt.transition,
+			Rect:         image.Rect(0, 0, tt.width, 1),
+		}
+		lg.Reset()
+		go lg.Run()
+		frames := readInChunks(t, lg, 4096)
+		frameSize := tt.width * 4
+		pixel := func(frame int, x int) color.RGBA {
+			p := frames[frame*frameSize+x*4:]
+			return color.RGBA{p[0], p[1], p[2], p[3]}
+		}
+		for seg := 1; seg <= 2; seg++ {
+			frame := seg * tt.transition
+			if got := pixel(frame, 0); got != testColors[seg] {
+				t.Errorf("%dx%d: frame %d starts with %v, want %v", tt.width, tt.transition, frame, got, testColors[seg])
+			}
+			// the last pixel is one pixel short of the next color
+			want := *mix(&testColors[seg], &testColors[seg+1], float64(tt.width-1)/float64(tt.width))
+			if got := pixel(frame, tt.width-1); got != want {
+				t.Errorf("%dx%d: frame %d ends with %v, want %v", tt.width, tt.transition, frame, got, want)
+			}
+		}
+	}
+}
+
+func TestLinearGradientStopColors(t *testing.T) {
+	accent := color.RGBA{17, 34, 51, 255}
+	width := 8
+	lg := &LinearGradient{
+		ColorChannel: colorChannel(testColors...),
+		Transition:   4,
+		Rect:         image.Rect(0, 0, width, 1),
+		Palette:      []*color.RGBA{&testColors[0], &accent},
+		StopColors:   map[int]int{1: 1},
+	}
+	lg.Reset()
+	go lg.Run()
+	frames := readInChunks(t, lg, 4096)
+	frameSize := width * 4
+	step := width / lg.Transition
+	checked := 0
+	for i := 0; (i+1)*frameSize <= len(frames); i++ {
+		// the middle stop is off the right edge on the first frame of each segment
+		shift := (i % lg.Transition) * step
+		if shift == 0 {
+			continue
+		}
+		p := frames[i*frameSize+(width-shift)*4:]
+		if got := (color.RGBA{p[0], p[1], p[2], p[3]}); got != accent {
+			t.Errorf("frame %d: middle stop is %v, want %v", i, got, accent)
+		}
+		checked++
+	}
+	if checked == 0 {
+		t.Fatal("no frames had the middle stop on screen")
+	}
+}
+
+func TestParseStopColors(t *testing.T) {
+	palette, stops, err := ParseStopColors([]string{"1=#ff8800", " 2=000010 ", "0=#f00"})
+	if err != nil {
+		t.Fatalf("parsing: %s", err)
+	}
+	if got := *palette[stops[1]]; got != (color.RGBA{255, 136, 0, 255}) {
+		t.Errorf("stop 1 is %v", got)
+	}
+	if got := *palette[stops[2]]; got != (color.RGBA{0, 0, 16, 255}) {
+		t.Errorf("stop 2 is %v", got)
+	}
+	// shorthand, as colormind.ParseHexColor parses every color
+	if got := *palette[stops[0]]; got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("stop 0 is %v", got)
+	}
+	for _, spec := range []string{"3=#ffffff", "1", "x=#ffffff", "1=#ffff", "1=#gggggg"} {
+		if _, _, err := ParseStopColors([]string{spec}); !errors.Is(err, ErrStopColor) {
+			t.Errorf("%q: got %v, want %v", spec, err, ErrStopColor)
+		}
+	}
+}
+
+func TestLinearGradientGrainSeed(t *testing.T) {
+	render := func(seed int64) []byte {
+		lg := &LinearGradient{
+			ColorChannel: colorChannel(testColors...),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, 8, 2),
+			Grain:        4,
+			Rand:         rand.New(rand.NewSource(seed)),
+		}
+		lg.Reset()
+		go lg.Run()
+		return readInChunks(t, lg, 4096)
+	}
+	a, b := render(1), render(1)
+	if len(a) == 0 || !bytes.Equal(a, b) {
+		t.Error("the same seed rendered different frames")
+	}
+	if bytes.Equal(a, render(2)) {
+		t.Error("different seeds rendered the same frames")
+	}
+}
+
+// Counts the random numbers drawn, each channel of grain draws one
+type countingSource struct {
+	rand.Source
+	n int
+}
+
+func (cs *countingSource) Int63() int64 {
+	cs.n++
+	return cs.Source.Int63()
+}
+
+func TestLinearGradientROI(t *testing.T) {
+	fill := color.RGBA{9, 9, 9, 255}
+	width, height := 8, 4
+	roi := image.Rect(2, 1, 6, 3)
+	src := &countingSource{Source: rand.NewSource(1)}
+	lg := &LinearGradient{
+		ColorChannel: colorChannel(testColors...),
+		Transition:   4,
+		Rect:         image.Rect(0, 0, width, height),
+		ROI:          roi,
+		Fill:         fill,
+		// grain counts the pixels rendered
+		Grain: 0.1,
+		Rand:  rand.New(src),
+	}
+	lg.Reset()
+	go lg.Run()
+	frames := readInChunks(t, lg, 7)
+	frameSize := width * height * 4
+	count := len(frames) / frameSize
+	pixel := func(frame, x, y int) color.RGBA {
+		p := frames[frame*frameSize+(y*width+x)*4:]
+		return color.RGBA{p[0], p[1], p[2], p[3]}
+	}
+	corner := map[color.RGBA]bool{}
+	for f := 0; f < count; f++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if image.Pt(x, y).In(roi) {
+					continue
+				}
+				if got := pixel(f, x, y); got != fill {
+					t.Fatalf("frame %d: pixel %d,%d outside the ROI is %v, want %v", f, x, y, got, fill)
+				}
+			}
+		}
+		corner[pixel(f, roi.Min.X, roi.Min.Y)] = true
+		if pixel(f, roi.Min.X, roi.Min.Y) != pixel(f, roi.Min.X, roi.Max.Y-1) {
+			t.Errorf("frame %d: rows inside the ROI differ", f)
+		}
+	}
+	if len(corner) < 2 {
+		t.Error("the ROI doesn't animate")
+	}
+	if want := count * roi.Dx() * 3; src.n != want {
+		t.Errorf("drew %d random numbers for %d frames, want %d, one per channel of the ROI's row", src.n, count, want)
+	}
+}
+
+func TestParseROI(t *testing.T) {
+	got, err := ParseROI("10, 20,300,40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := image.Rect(10, 20, 310, 60); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, s := range []string{"1,2,3", "a,2,3,4", "1,2,0,4"} {
+		if _, err := ParseROI(s); !errors.Is(err, ErrROI) {
+			t.Errorf("%q: got %v, want %v", s, err, ErrROI)
+		}
+	}
+}
+
+func TestLinearGradientSmooth(t *testing.T) {
+	left := color.RGBA{0, 0, 0, 255}
+	middle := color.RGBA{40, 40, 40, 255}
+	right := color.RGBA{255, 255, 255, 255}
+	width := 64
+	// the middle stop sits in the middle of the row
+	w := float64(width)
+	stops := [3]float64{-w / 2, w / 2, 3 * w / 2}
+	// difference between the slope either side of the middle stop
+	kink := func(smooth bool) float64 {
+		lg := &LinearGradient{Smooth: smooth}
+		row := lg.row(&left, &middle, &right, stops, 0, width)
+		at := func(x int) float64 { return float64(row.Pix[x*4]) }
+		mid := width / 2
+		before := (at(mid) - at(mid-4)) / 4
+		after := (at(mid+4) - at(mid)) / 4
+		return math.Abs(after - before)
+	}
+	linear, smooth := kink(false), kink(true)
+	if smooth >= linear/2 {
+		t.Errorf("slope changes by %.2f across the middle stop when smoothed, %.2f without", smooth, linear)
+	}
+	// the stops themselves are unchanged
+	lg := &LinearGradient{Smooth: true}
+	row := lg.row(&left, &middle, &right, stops, 0, width)
+	if got := row.RGBAAt(width/2, 0); got != middle {
+		t.Errorf("middle stop is %v, want %v", got, middle)
+	}
+}
+
+func TestLinearGradientTransitionMaxDelta(t *testing.T) {
+	a := color.RGBA{0, 0, 0, 255}
+	b := color.RGBA{255, 40, 0, 255}
+	for _, space := range []MixSpace{MixSRGB, MixLinear, MixOKLab} {
+		got := readTransitionColors(t, &LinearGradientTransition{
+			ColorChannel: colorChannel(a, b, a),
+			Transition:   4,
+			MixSpace:     space,
+			MaxDelta:     10,
+		})
+		// 255 levels 10 at a time, there and back
+		if len(got) < 2*26 {
+			t.Errorf("%s: got %d frames, want the transitions stretched to at least 26 each", space, len(got))
+		}
+		for i := 1; i < len(got); i++ {
+			p, c := got[i-1], got[i]
+			for _, d := range []int{int(c.R) - int(p.R), int(c.G) - int(p.G), int(c.B) - int(p.B)} {
+				if d > 10 || d < -10 {
+					t.Fatalf("%s: frame %d jumps from %v to %v", space, i, p, c)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkLinearGradient(b *testing.B) {
+	rois := map[string]image.Rectangle{
+		"full": {},
+		"roi":  image.Rect(480, 270, 1440, 810),
+	}
+	for name, roi := range rois {
+		b.Run(name, func(b *testing.B) {
+			colors := make(chan *color.RGBA)
+			lg := &LinearGradient{
+				ColorChannel: colors,
+				Transition:   30,
+				Rect:         image.Rect(0, 0, 1920, 1080),
+				ROI:          roi,
+				Fill:         color.RGBA{A: 255},
+			}
+			lg.Reset()
+			stopped := lg.stopped()
+			go func() {
+				for i := 0; ; i++ {
+					select {
+					case colors <- &testColors[i%len(testColors)]:
+					case <-stopped:
+						return
+					}
+				}
+			}()
+			go lg.Run()
+			defer lg.Close()
+			buf := make([]byte, 1920*1080*4)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := io.ReadFull(lg, buf); err != nil {
+					b.Fatalf("reading frame %d: %s", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLinearGradientTransitionLengths(t *testing.T) {
+	width := 8
+	run := func(transition int) [][]byte {
+		lg := &LinearGradient{
+			ColorChannel: colorChannel(testColors...),
+			Transition:   transition,
+			Rect:         image.Rect(0, 0, width, 1),
+		}
+		lg.Reset()
+		go lg.Run()
+		out := readInChunks(t, lg, 4096)
+		frames := [][]byte{}
+		for len(out) >= width*4 {
+			frames = append(frames, out[:width*4])
+			out = out[width*4:]
+		}
+		return frames
+	}
+	pixel := func(f []byte, x int) color.RGBA {
+		return color.RGBA{f[x*4], f[x*4+1], f[x*4+2], f[x*4+3]}
+	}
+
+	// a transition as long as the width moves a pixel a frame
+	frames := run(width)
+	if len(frames) != 3*width {
+		t.Fatalf("transition %d: got %d frames, want %d", width, len(frames), 3*width)
+	}
+	for i := 1; i < width; i++ {
+		if got, want := pixel(frames[i], 0), pixel(frames[0], i); got != want {
+			t.Errorf("transition %d: frame %d starts with %v, want %v", width, i, got, want)
+		}
+	}
+
+	// a one frame transition starts every frame on the next color
+	frames = run(1)
+	for i, f := range frames {
+		if got := pixel(f, 0); got != testColors[i] {
+			t.Errorf("transition 1: frame %d starts with %v, want %v", i, got, testColors[i])
+		}
+	}
+
+	// a transition longer than the width moves in fractions of a pixel, so every frame
+	// is different and none stall
+	transition := 4 * width
+	frames = run(transition)
+	for i := 1; i < len(frames); i++ {
+		if string(frames[i]) == string(frames[i-1]) {
+			t.Errorf("transition %d: frame %d is the same as the one before", transition, i)
+		}
+	}
+	if got := pixel(frames[transition], 0); got != testColors[1] {
+		t.Errorf("transition %d: second segment starts with %v, want %v", transition, got, testColors[1])
+	}
+
+	// transitions under a frame are refused rather than dividing by zero
+	for _, transition := range []int{0, -1} {
+		if frames := run(transition); len(frames) != 0 {
+			t.Errorf("transition %d: got %d frames, want none", transition, len(frames))
+		}
+	}
+}
+
+func TestLinearGradientTransitionSolidFrames(t *testing.T) {
+	from, to := color.RGBA{R: 10, G: 200, B: 40, A: 255}, color.RGBA{R: 250, G: 20, B: 120, A: 255}
+	lgt := &LinearGradientTransition{
+		ColorChannel: colorChannel(from, to),
+		Transition:   4,
+		ImageWidth:   3,
+		ImageHeight:  2,
+	}
+	lgt.Reset()
+	go lgt.Run()
+	out, err := io.ReadAll(lgt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frameSize := 3 * 2 * 4
+	if len(out) != 4*frameSize {
+		t.Fatalf("got %d bytes, want 4 frames", len(out))
+	}
+	for f := 0; f < 4; f++ {
+		want := mix(&from, &to, float64(f)/4)
+		frame := out[f*frameSize : (f+1)*frameSize]
+		for i := 0; i < len(frame); i += 4 {
+			if got := (color.RGBA{frame[i], frame[i+1], frame[i+2], frame[i+3]}); got != *want {
+				t.Fatalf("frame %d pixel %d is %v, want %v", f, i/4, got, *want)
+			}
+		}
+	}
+}
+
+func TestLinearGradientSpeed(t *testing.T) {
+	width := 8
+	for _, speed := range []float64{2, 0.5, 1.5} {
+		lg := &LinearGradient{
+			ColorChannel: colorChannel(testColors...),
+			// ignored for the pace
+			Transition: 90,
+			Speed:      speed,
+			Rect:       image.Rect(0, 0, width, 1),
+		}
+		lg.Reset()
+		go lg.Run()
+		out := readInChunks(t, lg, 4096)
+		frameSize := width * 4
+		// moving a whole width at a time through three segments
+		if want := int(3 * float64(width) / speed); len(out) != want*frameSize {
+			t.Errorf("speed %g: got %d frames, want %d", speed, len(out)/frameSize, want)
+		}
+		for f := 1; f*frameSize < len(out); f++ {
+			moved := float64(f) * speed
+			if moved != math.Trunc(moved) || moved >= float64(width) {
+				continue
+			}
+			// the pattern has moved left by whole pixels
+			got := out[f*frameSize : f*frameSize+(width-int(moved))*4]
+			want := out[int(moved)*4 : frameSize]
+			if !bytes.Equal(got, want) {
+				t.Errorf("speed %g: frame %d isn't frame 0 moved %g pixels", speed, f, moved)
+			}
+		}
+	}
+}
+
+func TestLinearGradientReadRows(t *testing.T) {
+	width, height := 3, 10
+	rowSize := width * 4
+	for _, dither := range []bool{false, true} {
+		lg := &LinearGradient{
+			ColorChannel: colorChannel(testColors...),
+			Transition:   4,
+			Rect:         image.Rect(2, 5, 2+width, 5+height),
+			Dither:       dither,
+		}
+		lg.Reset()
+		go lg.Run()
+		// 5 bytes at a time ends reads mid pixel and across rows
+		out := readInChunks(t, lg, 5)
+		frameSize := rowSize * height
+		if len(out) == 0 || len(out)%frameSize != 0 {
+			t.Fatalf("dither %t: read %d bytes, not whole frames", dither, len(out))
+		}
+		// dithered frames repeat their 4 rows down the frame, otherwise the first
+		period := lg.rows()
+		for f := 0; f < len(out)/frameSize; f++ {
+			frame := out[f*frameSize : (f+1)*frameSize]
+			for y := period; y < height; y++ {
+				got := frame[y*rowSize : (y+1)*rowSize]
+				want := frame[(y%period)*rowSize : (y%period+1)*rowSize]
+				if !bytes.Equal(got, want) {
+					t.Errorf("dither %t: frame %d row %d is % x, want row %d % x", dither, f, y, got, y%period, want)
+				}
+			}
+		}
+	}
+}
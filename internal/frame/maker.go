@@ -0,0 +1,79 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// Pixel format of the frames every maker reads out, in ffmpeg's naming
+const PixelFormat = "rgba"
+
+// Produces raw frames for an output
+type Maker interface {
+	io.ReadCloser
+	Run()
+	// Prepares the maker for a new Run
+	Reset()
+	// Hold and carry on rendering frames, without losing Run's place
+	Pause()
+	Resume()
+	// Size of the frames Read returns
+	Bounds() image.Rectangle
+	// ffmpeg pix_fmt of the frames Read returns
+	PixelFormat() string
+}
+
+// Which frame maker animates the colors
+type Effect string
+
+const (
+	// A gradient sliding to the left, LinearGradient
+	EffectScroll Effect = "scroll"
+	// The whole frame fading from one color to the next, LinearGradientTransition
+	EffectFade Effect = "fade"
+	// Rings of color pulsing outward from the center, RadialGradient
+	EffectPulse Effect = "pulse"
+	// A gradient through several colors at once sliding to the left, MultiStopGradient
+	EffectStops Effect = "stops"
+)
+
+// Every effect, in the order they're documented
+var Effects = []Effect{EffectScroll, EffectFade, EffectPulse, EffectStops}
+
+// Other names the effects go by
+var effectAliases = map[string]Effect{
+	// the whole frame is one solid color breathing from one to the next
+	"solid-fade": EffectFade,
+}
+
+var ErrEffect = errors.New("unknown effect")
+
+func ParseEffect(s string) (Effect, error) {
+	for _, effect := range Effects {
+		if Effect(s) == effect {
+			return effect, nil
+		}
+	}
+	if effect, ok := effectAliases[s]; ok {
+		return effect, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrEffect, s)
+}
+
+func (lgis *LinearGradient) Bounds() image.Rectangle {
+	return lgis.Rect
+}
+
+func (lgis *LinearGradient) PixelFormat() string {
+	return PixelFormat
+}
+
+func (lgt *LinearGradientTransition) Bounds() image.Rectangle {
+	return image.Rect(0, 0, lgt.ImageWidth, lgt.ImageHeight)
+}
+
+func (lgt *LinearGradientTransition) PixelFormat() string {
+	return PixelFormat
+}
@@ -0,0 +1,20 @@
+package frame
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseEffect(t *testing.T) {
+	for _, s := range []string{"scroll", "fade", "pulse", "stops"} {
+		if effect, err := ParseEffect(s); err != nil || string(effect) != s {
+			t.Errorf("ParseEffect(%q) = %q, %v", s, effect, err)
+		}
+	}
+	if effect, err := ParseEffect("solid-fade"); err != nil || effect != EffectFade {
+		t.Errorf("ParseEffect(solid-fade) = %q, %v, want the fade", effect, err)
+	}
+	if _, err := ParseEffect("spin"); !errors.Is(err, ErrEffect) {
+		t.Errorf("got %v, want ErrEffect", err)
+	}
+}
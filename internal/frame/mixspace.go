@@ -0,0 +1,113 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/broganross/color-run/internal/colorspace"
+)
+
+var ErrMixSpace = errors.New("unknown mix space")
+
+// Color space two colors are interpolated in.  Frames are always sRGB encoded.
+type MixSpace string
+
+const (
+	// Interpolates the encoded values, the original behaviour
+	MixSRGB MixSpace = "srgb"
+	// Interpolates linear light, which keeps mid points from looking too dark
+	MixLinear MixSpace = "linear"
+	// Interpolates in OKLab, which keeps hue and lightness perceptually even
+	MixOKLab MixSpace = "oklab"
+)
+
+func ParseMixSpace(s string) (MixSpace, error) {
+	switch space := MixSpace(s); space {
+	case MixSRGB, MixLinear, MixOKLab:
+		return space, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrMixSpace, s)
+}
+
+// Mixes the colors in the space.  Alpha is always mixed directly.
+func (ms MixSpace) mix(c1 rgbaf, c2 rgbaf, ratio float64) rgbaf {
+	if !ms.converts() {
+		return mixf(c1, c2, ratio)
+	}
+	a, b := ms.from(c1), ms.from(c2)
+	out := ms.to(mixf(a, b, ratio))
+	out[3] = c1[3]*(1-ratio) + c2[3]*ratio
+	return out
+}
+
+// Whether colors have to be converted to mix in the space, sRGB values are mixed as is
+func (ms MixSpace) converts() bool {
+	return ms == MixLinear || ms == MixOKLab
+}
+
+// Converts from 8 bit sRGB values to the space
+func (ms MixSpace) from(c rgbaf) rgbaf {
+	r := colorspace.SRGBToLinear(c[0] / 255)
+	g := colorspace.SRGBToLinear(c[1] / 255)
+	b := colorspace.SRGBToLinear(c[2] / 255)
+	if ms == MixOKLab {
+		r, g, b = colorspace.LinearToOKLab(r, g, b)
+	}
+	return rgbaf{r, g, b, c[3]}
+}
+
+// Converts from the space back to 8 bit sRGB values
+func (ms MixSpace) to(c rgbaf) rgbaf {
+	r, g, b := c[0], c[1], c[2]
+	if ms == MixOKLab {
+		r, g, b = colorspace.OKLabToLinear(r, g, b)
+	}
+	out := rgbaf{r, g, b, c[3]}
+	for i := 0; i < 3; i++ {
+		// OKLab mixes can land slightly outside the gamut
+		v := colorspace.LinearToSRGB(min(max(out[i], 0), 1)) * 255
+		// quantizing truncates, so don't let the round trip's rounding error turn
+		// 255 into 254
+		if r := math.Round(v); math.Abs(v-r) < 1e-6 {
+			v = r
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// Interpolates from p0 to p1 in the space along a cubic with the tangents m0 and m1,
+// given in the space.  Alpha is mixed linearly.
+func (ms MixSpace) hermite(p0 rgbaf, p1 rgbaf, m0 rgbaf, m1 rgbaf, t float64) rgbaf {
+	t2, t3 := t*t, t*t*t
+	h00 := 2*t3 - 3*t2 + 1
+	h10 := t3 - 2*t2 + t
+	h01 := -2*t3 + 3*t2
+	h11 := t3 - t2
+	alpha := p0[3]*(1-t) + p1[3]*t
+	if ms.converts() {
+		p0, p1 = ms.from(p0), ms.from(p1)
+	}
+	var out rgbaf
+	for i := 0; i < 3; i++ {
+		out[i] = h00*p0[i] + h10*m0[i] + h01*p1[i] + h11*m1[i]
+	}
+	if ms.converts() {
+		out = ms.to(out)
+	}
+	out[3] = alpha
+	return out
+}
+
+// Tangent at a color given its neighbours, in the space
+func (ms MixSpace) tangent(before rgbaf, after rgbaf, scale float64) rgbaf {
+	if ms.converts() {
+		before, after = ms.from(before), ms.from(after)
+	}
+	var out rgbaf
+	for i := range out {
+		out[i] = (after[i] - before[i]) * scale
+	}
+	return out
+}
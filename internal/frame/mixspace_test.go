@@ -0,0 +1,120 @@
+package frame
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/broganross/color-run/internal/colorspace"
+)
+
+func TestMixSpace(t *testing.T) {
+	black := rgbaf{0, 0, 0, 255}
+	white := rgbaf{255, 255, 255, 255}
+	tests := []struct {
+		space MixSpace
+		want  float64
+	}{
+		{MixSRGB, 127.5},
+		// half the light of white is encoded well above half way
+		{MixLinear, 187.5},
+		// half OKLab lightness is an eighth of the light
+		{MixOKLab, 99.1},
+	}
+	for _, tt := range tests {
+		got := tt.space.mix(black, white, 0.5)
+		if math.Abs(got[0]-tt.want) > 0.5 || math.Abs(got[0]-got[1]) > 1e-3 || math.Abs(got[1]-got[2]) > 1e-3 {
+			t.Errorf("%s: mid point is %v, want grey %g", tt.space, got, tt.want)
+		}
+		if got[3] != 255 {
+			t.Errorf("%s: alpha is %g", tt.space, got[3])
+		}
+		for _, end := range []float64{0, 1} {
+			want := mixf(black, white, end)
+			if got := tt.space.mix(black, white, end); math.Abs(got[0]-want[0]) > 1e-6 {
+				t.Errorf("%s: end %g is %v, want %v", tt.space, end, got, want)
+			}
+		}
+	}
+	if _, err := ParseMixSpace("hsv"); !errors.Is(err, ErrMixSpace) {
+		t.Errorf("got %v, want %v", err, ErrMixSpace)
+	}
+}
+
+// Lightness of an 8 bit sRGB color in OKLab
+func oklabLightness(c rgbaf) float64 {
+	l, _, _ := colorspace.LinearToOKLab(
+		colorspace.SRGBToLinear(c[0]/255),
+		colorspace.SRGBToLinear(c[1]/255),
+		colorspace.SRGBToLinear(c[2]/255),
+	)
+	return l
+}
+
+func TestMixSpaceRedGreen(t *testing.T) {
+	red := rgbaf{255, 0, 0, 255}
+	green := rgbaf{0, 255, 0, 255}
+	even := (oklabLightness(red) + oklabLightness(green)) / 2
+	tests := []struct {
+		space MixSpace
+		want  rgbaf
+		// how far the mid point's lightness falls short of half way between the ends
+		dip float64
+	}{
+		// the muddy olive sRGB mixing is known for
+		{MixSRGB, rgbaf{127.5, 127.5, 0, 255}, 0.168},
+		// linear light overshoots a little
+		{MixLinear, rgbaf{187.5, 187.5, 0, 255}, -0.021},
+		// perceptually half way, a bright orange-yellow
+		{MixOKLab, rgbaf{208.2, 168.4, 0.5, 255}, 0},
+	}
+	for _, tt := range tests {
+		got := tt.space.mix(red, green, 0.5)
+		for i := range got {
+			if math.Abs(got[i]-tt.want[i]) > 0.5 {
+				t.Errorf("%s: mid point is %v, want %v", tt.space, got, tt.want)
+				break
+			}
+		}
+		if dip := even - oklabLightness(got); math.Abs(dip-tt.dip) > 0.005 {
+			t.Errorf("%s: mid point is %.3f darker than half way, want %.3f", tt.space, dip, tt.dip)
+		}
+	}
+}
+
+func TestMixSpaceGamut(t *testing.T) {
+	colors := []rgbaf{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255}, {0, 255, 255, 255}, {255, 255, 255, 255}, {0, 0, 0, 255}}
+	for _, a := range colors {
+		for _, b := range colors {
+			for ratio := 0.0; ratio <= 1; ratio += 0.125 {
+				got := MixOKLab.mix(a, b, ratio)
+				for _, v := range got[:3] {
+					if v < 0 || v > 255 || math.IsNaN(v) {
+						t.Fatalf("mixing %v and %v at %g gave %v, outside the gamut", a, b, ratio, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestLinearGradientMixSpaceRedWhite(t *testing.T) {
+	red := &color.RGBA{255, 0, 0, 255}
+	white := &color.RGBA{255, 255, 255, 255}
+	for _, tt := range []struct {
+		space MixSpace
+		want  color.RGBA
+	}{
+		// averaging the encoded values leaves the middle a dull salmon
+		{MixSRGB, color.RGBA{255, 127, 127, 255}},
+		// half the light of each is the pink the eye expects
+		{MixLinear, color.RGBA{255, 187, 187, 255}},
+	} {
+		lg := &LinearGradient{Rect: image.Rect(0, 0, 10, 1), Palette: []*color.RGBA{red, white}, MixSpace: tt.space}
+		if got := lg.FrameAt(0).RGBAAt(5, 0); got != tt.want {
+			t.Errorf("%s: mid point is %v, want %v", tt.space, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"io"
+)
+
+// Creates frames which show a gradient through Stops colors spaced evenly across the
+// width, sliding to the left one stop every Transition frames as new colors come in
+// on the right
+type MultiStopGradient struct {
+	stopper
+	ColorChannel chan *color.RGBA
+	imageChannel chan *image.RGBA
+	Transition   int
+	Rect         image.Rectangle
+	// Number of colors across the width, at least 2
+	Stops int
+	// Color space the gradient is interpolated in, sRGB when empty
+	MixSpace MixSpace
+	// Interpolate along a curve through the stops rather than mixing each pair linearly
+	Smooth bool
+	img    *image.RGBA
+	idx    int
+}
+
+func (msg *MultiStopGradient) Read(out []byte) (int, error) {
+	cnt := 0
+	l := len(out)
+	end := false
+	rowSize := msg.Rect.Dx() * 4
+	imageSize := rowSize * msg.Rect.Dy()
+	for cnt < l {
+		if msg.img == nil {
+			img, ok := <-msg.imageChannel
+			if !ok {
+				end = true
+				break
+			}
+			msg.img = img
+		}
+		// every row of the frame is the one rendered row
+		for msg.idx < imageSize && cnt < l {
+			n := copy(out[cnt:], msg.img.Pix[msg.idx%rowSize:rowSize])
+			msg.idx += n
+			cnt += n
+		}
+		if msg.idx >= imageSize {
+			msg.img = nil
+			msg.idx = 0
+		}
+	}
+	var err error
+	if end {
+		err = io.EOF
+	}
+	return cnt, err
+}
+
+// Clears any state left over from a previous Run and allocates the frame buffer
+// for the next one, so Read may be called as soon as Run is started.  It must only
+// be called once the previous Run has returned and Read has hit EOF, and must be
+// called between runs of the same instance.
+func (msg *MultiStopGradient) Reset() {
+	msg.img = nil
+	msg.idx = 0
+	msg.imageChannel = make(chan *image.RGBA, msg.Transition*3)
+	msg.reset()
+}
+
+func (msg *MultiStopGradient) Run() {
+	if msg.imageChannel == nil {
+		msg.imageChannel = make(chan *image.RGBA, msg.Transition*3)
+	}
+	// the visible stops and the one coming in from the right
+	window := make([]*color.RGBA, 0, msg.Stops+1)
+	width := msg.Rect.Dx()
+	segmentFrame := 0
+	done := false
+	stopped := msg.stopped()
+	getCol := func() *color.RGBA {
+		select {
+		case c, ok := <-msg.ColorChannel:
+			if !ok {
+				done = true
+			}
+			return c
+		case <-stopped:
+			done = true
+			return nil
+		}
+	}
+	for !done {
+		for len(window) < msg.Stops+1 && !done {
+			window = append(window, getCol())
+		}
+		if done || !msg.wait() {
+			break
+		}
+		shift := float64(segmentFrame) / float64(msg.Transition)
+		img := msg.row(window, shift, width)
+		select {
+		case msg.imageChannel <- img:
+		case <-stopped:
+			done = true
+		}
+		segmentFrame++
+		if segmentFrame >= msg.Transition {
+			segmentFrame = 0
+			window = append(window[:0], window[1:]...)
+		}
+	}
+	close(msg.imageChannel)
+}
+
+// Renders one row with the stops shifted left by shift of the distance between them.
+// The first and last pixel sit exactly on a stop when shift is 0.
+func (msg *MultiStopGradient) row(stops []*color.RGBA, shift float64, width int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, 1))
+	cols := make([]rgbaf, len(stops))
+	for i, c := range stops {
+		cols[i] = toRGBAf(c)
+	}
+	var tangents []rgbaf
+	if msg.Smooth {
+		// Catmull-Rom style tangents, the end stops have one neighbour so use it twice
+		tangents = make([]rgbaf, len(cols))
+		for i := range cols {
+			before, after, scale := max(i-1, 0), min(i+1, len(cols)-1), 0.5
+			if before == i || after == i {
+				scale = 1
+			}
+			tangents[i] = msg.MixSpace.tangent(cols[before], cols[after], scale)
+		}
+	}
+	span := max(width-1, 1)
+	for x := 0; x < width; x++ {
+		// found in integers so pixels on a stop land on it exactly
+		k := x * (msg.Stops - 1) / span
+		t := float64(x*(msg.Stops-1)%span)/float64(span) + shift
+		if t >= 1 {
+			k, t = k+1, t-1
+		}
+		if k > len(cols)-2 {
+			k, t = len(cols)-2, 1
+		}
+		var col rgbaf
+		if msg.Smooth {
+			col = msg.MixSpace.hermite(cols[k], cols[k+1], tangents[k], tangents[k+1], t)
+		} else {
+			col = msg.MixSpace.mix(cols[k], cols[k+1], t)
+		}
+		img.SetRGBA(x, 0, col.quantize(0))
+	}
+	return img
+}
+
+func (msg *MultiStopGradient) Bounds() image.Rectangle {
+	return msg.Rect
+}
+
+func (msg *MultiStopGradient) PixelFormat() string {
+	return PixelFormat
+}
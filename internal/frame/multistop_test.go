@@ -0,0 +1,77 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+func TestMultiStopGradient(t *testing.T) {
+	width, height := 13, 2
+	for _, smooth := range []bool{false, true} {
+		msg := &MultiStopGradient{
+			// a window of 5 colors, and one more to scroll a stop along
+			ColorChannel: colorChannel(append(testColors, testColors[0])...),
+			Transition:   2,
+			Rect:         image.Rect(0, 0, width, height),
+			Stops:        4,
+			Smooth:       smooth,
+		}
+		msg.Reset()
+		go msg.Run()
+		frames := make([][]byte, 3)
+		for i := range frames {
+			frames[i] = make([]byte, width*height*4)
+			if _, err := io.ReadFull(msg, frames[i]); err != nil {
+				t.Fatalf("smooth %t: reading frame %d: %s", smooth, i, err)
+			}
+		}
+		msg.Close()
+		io.Copy(io.Discard, msg)
+		pixel := func(frame []byte, x int) color.RGBA {
+			return color.RGBA{frame[x*4], frame[x*4+1], frame[x*4+2], frame[x*4+3]}
+		}
+		// 4 stops across 13 pixels are 4 apart
+		for stop := 0; stop < 4; stop++ {
+			if got := pixel(frames[0], stop*4); got != testColors[stop] {
+				t.Errorf("smooth %t: stop %d is %v, want %v", smooth, stop, got, testColors[stop])
+			}
+			// a transition later each stop holds the next color
+			if got := pixel(frames[2], stop*4); got != testColors[stop+1] {
+				t.Errorf("smooth %t: stop %d after a transition is %v, want %v", smooth, stop, got, testColors[stop+1])
+			}
+		}
+		// between stops the pixels are a mix
+		if got := pixel(frames[0], 2); got == testColors[0] || got == testColors[1] {
+			t.Errorf("smooth %t: pixel between the first stops is %v", smooth, got)
+		}
+		// every row is the same
+		if got := frames[0][width*4:]; string(got) != string(frames[0][:width*4]) {
+			t.Errorf("smooth %t: rows differ", smooth)
+		}
+	}
+}
+
+func TestMultiStopGradientEndpoints(t *testing.T) {
+	stops := make([]*color.RGBA, len(testColors))
+	for i := range testColors {
+		stops[i] = &testColors[i]
+	}
+	for _, smooth := range []bool{false, true} {
+		msg := &MultiStopGradient{Stops: len(stops), Smooth: smooth}
+		// widths the segments divide evenly, unevenly, and narrower than the stops
+		for _, width := range []int{2, 3, 4, 5, 6, 7, 13, 64, 99, 100, 1920} {
+			// scrolled part way the last segment is cut short, and mustn't index past the stops
+			msg.row(stops, 0.5, width)
+			msg.row(stops, 0.99, width)
+			row := msg.row(stops, 0, width)
+			if got := row.RGBAAt(0, 0); got != testColors[0] {
+				t.Errorf("width %d: first pixel is %v, want the first color %v", width, got, testColors[0])
+			}
+			if got, want := row.RGBAAt(width-1, 0), testColors[len(testColors)-1]; got != want {
+				t.Errorf("width %d: last pixel is %v, want the last color %v", width, got, want)
+			}
+		}
+	}
+}
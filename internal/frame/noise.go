@@ -0,0 +1,68 @@
+package frame
+
+// Levels a pixel moves at most at an Intensity of 1
+const noiseLevels = 64
+
+// Adds film grain to the frames of another maker, which shimmers as it's drawn afresh
+// for every frame.  Each pixel is made lighter or darker by the same amount in every
+// channel, which also dithers away banding in smooth gradients.  Alpha is left alone.
+// The grain only depends on Seed, the frame and the pixel, so frames come out the same
+// however they're read.
+type Noise struct {
+	Maker
+	// From 0, which leaves the frames as they are, to 1, which moves pixels up to 64
+	// levels either way
+	Intensity float64
+	Seed      int64
+	// bytes of the current frame read, and frames read before it
+	idx   int
+	frame uint64
+}
+
+func (n *Noise) Read(out []byte) (int, error) {
+	cnt, err := n.Maker.Read(out)
+	size := n.Bounds().Dx() * n.Bounds().Dy() * 4
+	if n.Intensity <= 0 || size == 0 {
+		n.advance(cnt, size)
+		return cnt, err
+	}
+	for i := 0; i < cnt; i++ {
+		if ch := n.idx & 3; ch != 3 {
+			v := float64(out[i]) + n.grain(n.idx/4)
+			out[i] = uint8(min(max(v+0.5, 0), 255))
+		}
+		n.advance(1, size)
+	}
+	return cnt, err
+}
+
+// Moves the read position on by cnt bytes, wrapping into the next frame
+func (n *Noise) advance(cnt int, size int) {
+	if size == 0 {
+		return
+	}
+	n.idx += cnt
+	n.frame += uint64(n.idx / size)
+	n.idx %= size
+}
+
+// Levels the pixel is moved by in the current frame
+func (n *Noise) grain(pixel int) float64 {
+	h := mix64(uint64(n.Seed) ^ mix64(n.frame^mix64(uint64(pixel))))
+	// the top 53 bits as a float from -1 to 1
+	return (float64(h>>11)/(1<<52) - 1) * n.Intensity * noiseLevels
+}
+
+// splitmix64's finalizer, spreading every bit of x across the result
+func mix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+func (n *Noise) Reset() {
+	n.idx = 0
+	n.frame = 0
+	n.Maker.Reset()
+}
@@ -0,0 +1,68 @@
+package frame
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"testing"
+)
+
+func TestNoise(t *testing.T) {
+	newGradient := func() Maker {
+		return &LinearGradient{
+			ColorChannel: colorChannel(testColors...),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, 8, 2),
+		}
+	}
+	plain := newGradient()
+	plain.Reset()
+	go plain.Run()
+	want := readInChunks(t, plain, 4096)
+	off := &Noise{Maker: newGradient(), Seed: 1}
+	off.Reset()
+	go off.Run()
+	if got := readInChunks(t, off, 4096); !bytes.Equal(got, want) {
+		t.Error("frames changed with no intensity")
+	}
+
+	frameSize := 8 * 2 * 4
+	read := func(chunk int) []byte {
+		n := &Noise{Maker: newSlowMaker(image.Pt(8, 2), 1<<40), Intensity: 0.5, Seed: 1}
+		n.Reset()
+		out := make([]byte, 0, 3*frameSize)
+		buf := make([]byte, chunk)
+		for len(out) < cap(out) {
+			c, err := io.ReadFull(n, buf[:min(chunk, cap(out)-len(out))])
+			if err != nil {
+				t.Fatalf("reading: %s", err)
+			}
+			out = append(out, buf[:c]...)
+		}
+		return out
+	}
+	// blank frames, so everything left is grain
+	got := read(frameSize)
+	for f := 1; f < 3; f++ {
+		if bytes.Equal(got[:frameSize], got[f*frameSize:(f+1)*frameSize]) {
+			t.Errorf("frame %d has the same grain as frame 0", f)
+		}
+	}
+	for i := 0; i < len(got); i += 4 {
+		if got[i] != got[i+1] || got[i] != got[i+2] {
+			t.Errorf("pixel %d is % x, want the same grain in every channel", i/4, got[i:i+4])
+		}
+		if got[i+3] != 0 {
+			t.Errorf("pixel %d alpha is %d, want it left alone", i/4, got[i+3])
+		}
+		if got[i] > 32 {
+			t.Errorf("pixel %d moved %d levels, want at most 32 at half intensity", i/4, got[i])
+		}
+	}
+	if !bytes.ContainsFunc(got, func(r rune) bool { return r != 0 }) {
+		t.Error("no grain at half intensity")
+	}
+	if odd := read(5); !bytes.Equal(odd, got) {
+		t.Error("reading 5 bytes at a time changed the grain")
+	}
+}
@@ -0,0 +1,135 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+
+	imgenc "github.com/broganross/color-run/internal/image"
+)
+
+var (
+	ErrPNGDumpClose = errors.New("png dump is closed")
+	ErrPNGDumpSize  = errors.New("invalid png dump frame size")
+)
+
+// A whole frame waiting to be encoded
+type numberedFrame struct {
+	idx int
+	pix []byte
+}
+
+// Writes every frame written to it into a directory as frame_000000.png onwards,
+// numbered by their index in the stream.  Frames are encoded on another goroutine so
+// the stream isn't held up.  Frames arriving while Buffer others are waiting are
+// dropped rather than waited for, which leaves gaps in the numbering.
+type PNGDumpWriter struct {
+	mu      sync.Mutex
+	closed  bool
+	dir     string
+	rect    image.Rectangle
+	frame   []byte
+	idx     int
+	dropped int
+	encoder imgenc.Encoder
+	frames  chan numberedFrame
+	done    chan struct{}
+	// first error encoding or writing a frame
+	err error
+}
+
+// Frames are compressed at level, png.BestSpeed keeping up with larger frames than
+// the default
+func NewPNGDumpWriter(dir string, width int, height int, buffer int, level png.CompressionLevel) (*PNGDumpWriter, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrPNGDumpSize, width, height)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating png dump directory: %w", err)
+	}
+	pdw := &PNGDumpWriter{
+		dir:     dir,
+		rect:    image.Rect(0, 0, width, height),
+		encoder: imgenc.PNGEncoder(level),
+		frames:  make(chan numberedFrame, max(buffer, 1)),
+		done:    make(chan struct{}),
+	}
+	pdw.frame = make([]byte, 0, width*height*4)
+	go pdw.encode()
+	return pdw, nil
+}
+
+func (pdw *PNGDumpWriter) Write(b []byte) (int, error) {
+	pdw.mu.Lock()
+	defer pdw.mu.Unlock()
+	if pdw.closed {
+		return 0, ErrPNGDumpClose
+	}
+	n := len(b)
+	for len(b) > 0 {
+		take := min(len(b), cap(pdw.frame)-len(pdw.frame))
+		pdw.frame = append(pdw.frame, b[:take]...)
+		b = b[take:]
+		if len(pdw.frame) < cap(pdw.frame) {
+			break
+		}
+		select {
+		case pdw.frames <- numberedFrame{idx: pdw.idx, pix: pdw.frame}:
+			pdw.frame = make([]byte, 0, cap(pdw.frame))
+		default:
+			pdw.dropped++
+			pdw.frame = pdw.frame[:0]
+		}
+		pdw.idx++
+	}
+	return n, nil
+}
+
+func (pdw *PNGDumpWriter) encode() {
+	defer close(pdw.done)
+	for f := range pdw.frames {
+		if err := pdw.writeFrame(f); err != nil && pdw.err == nil {
+			pdw.err = err
+		}
+	}
+}
+
+func (pdw *PNGDumpWriter) writeFrame(f numberedFrame) error {
+	path := filepath.Join(pdw.dir, fmt.Sprintf("frame_%06d.png", f.idx))
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating frame: %w", err)
+	}
+	img := &image.RGBA{Pix: f.pix, Stride: pdw.rect.Dx() * 4, Rect: pdw.rect}
+	if err := pdw.encoder(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("encoding frame %d: %w", f.idx, err)
+	}
+	return file.Close()
+}
+
+// Number of frames dropped because the encoder was behind
+func (pdw *PNGDumpWriter) Dropped() int {
+	pdw.mu.Lock()
+	defer pdw.mu.Unlock()
+	return pdw.dropped
+}
+
+// Waits for the frames already written to be encoded, returning the first error doing
+// so.  A partly written frame is discarded.
+func (pdw *PNGDumpWriter) Close() error {
+	pdw.mu.Lock()
+	if pdw.closed {
+		pdw.mu.Unlock()
+		return nil
+	}
+	pdw.closed = true
+	close(pdw.frames)
+	pdw.mu.Unlock()
+	<-pdw.done
+	return pdw.err
+}
@@ -0,0 +1,66 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPNGDumpWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "frames")
+	pdw, err := NewPNGDumpWriter(dir, 2, 1, 8, png.BestSpeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw []byte
+	for i := 0; i < 3; i++ {
+		raw = append(raw, byte(i), 0, 0, 255, 0, byte(i), 0, 255)
+	}
+	// split unevenly so frames span writes, with half a frame left over
+	raw = append(raw, 9, 9, 9, 255)
+	for _, chunk := range [][]byte{raw[:3], raw[3:13], raw[13:]} {
+		if _, err := pdw.Write(chunk); err != nil {
+			t.Fatalf("writing: %s", err)
+		}
+	}
+	if err := pdw.Close(); err != nil {
+		t.Fatalf("closing: %s", err)
+	}
+	if _, err := pdw.Write(raw[:4]); err != ErrPNGDumpClose {
+		t.Errorf("got %v writing after close, want %v", err, ErrPNGDumpClose)
+	}
+	if _, err := NewPNGDumpWriter(dir, 0, 1, 8, png.BestSpeed); !errors.Is(err, ErrPNGDumpSize) {
+		t.Errorf("zero width returned %v, want %v", err, ErrPNGDumpSize)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d files, want a png for each whole frame", len(entries))
+	}
+	for i := 0; i < 3; i++ {
+		f, err := os.Open(filepath.Join(dir, fmt.Sprintf("frame_%06d.png", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("decoding frame %d: %s", i, err)
+		}
+		if got, want := color.RGBAModel.Convert(img.At(0, 0)), (color.RGBA{R: uint8(i), A: 255}); got != want {
+			t.Errorf("frame %d left pixel is %v, want %v", i, got, want)
+		}
+		if got, want := color.RGBAModel.Convert(img.At(1, 0)), (color.RGBA{G: uint8(i), A: 255}); got != want {
+			t.Errorf("frame %d right pixel is %v, want %v", i, got, want)
+		}
+	}
+	if n := pdw.Dropped(); n != 0 {
+		t.Errorf("dropped %d frames with room for all of them", n)
+	}
+}
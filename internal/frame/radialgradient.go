@@ -0,0 +1,169 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"sync"
+)
+
+// Number of frames buffered by makers which render every pixel.  Buffering whole
+// transitions like the others would take gigabytes at 1080p.
+const fullFrameBuffer = 8
+
+// Creates frames which show rings of color pulsing outward from the center.  Each new
+// color appears at the center and takes Transition frames to push the one before it
+// out to the corners.
+type RadialGradient struct {
+	stopper
+	ColorChannel chan *color.RGBA
+	imageChannel chan *image.RGBA
+	Transition   int
+	Rect         image.Rectangle
+	// Color space the rings are interpolated in, sRGB when empty
+	MixSpace MixSpace
+	img      *image.RGBA
+	idx      int
+	// frames Read has finished with, for Run to render the next ones into
+	frames sync.Pool
+}
+
+func (rg *RadialGradient) Read(out []byte) (int, error) {
+	cnt := 0
+	l := len(out)
+	end := false
+	for cnt < l {
+		if rg.img == nil {
+			img, ok := <-rg.imageChannel
+			if !ok {
+				end = true
+				break
+			}
+			rg.img = img
+		}
+		n := copy(out[cnt:], rg.img.Pix[rg.idx:])
+		rg.idx += n
+		cnt += n
+		if rg.idx >= len(rg.img.Pix) {
+			rg.frames.Put(rg.img)
+			rg.img = nil
+			rg.idx = 0
+		}
+	}
+	var err error
+	if end {
+		err = io.EOF
+	}
+	return cnt, err
+}
+
+// Clears any state left over from a previous Run and allocates the frame buffer
+// for the next one, so Read may be called as soon as Run is started.  It must only
+// be called once the previous Run has returned and Read has hit EOF, and must be
+// called between runs of the same instance.
+func (rg *RadialGradient) Reset() {
+	rg.img = nil
+	rg.idx = 0
+	rg.imageChannel = make(chan *image.RGBA, fullFrameBuffer)
+	rg.reset()
+}
+
+func (rg *RadialGradient) Run() {
+	if rg.imageChannel == nil {
+		rg.imageChannel = make(chan *image.RGBA, fullFrameBuffer)
+	}
+	var outer *color.RGBA
+	var middle *color.RGBA
+	var inner *color.RGBA
+	pos := rg.positions()
+	segmentFrame := 0
+	done := false
+	stopped := rg.stopped()
+	getCol := func() *color.RGBA {
+		select {
+		case c, ok := <-rg.ColorChannel:
+			if !ok {
+				done = true
+			}
+			return c
+		case <-stopped:
+			done = true
+			return nil
+		}
+	}
+	for !done {
+		if outer == nil {
+			outer = getCol()
+		}
+		if middle == nil {
+			middle = getCol()
+		}
+		if inner == nil {
+			inner = getCol()
+		}
+		if done || !rg.wait() {
+			break
+		}
+		shift := float64(segmentFrame) / float64(rg.Transition)
+		img := rg.frame(outer, middle, inner, pos, shift)
+		select {
+		case rg.imageChannel <- img:
+		case <-stopped:
+			done = true
+		}
+		segmentFrame++
+		if segmentFrame >= rg.Transition {
+			segmentFrame = 0
+			outer = middle
+			middle = inner
+			inner = nil
+		}
+	}
+	close(rg.imageChannel)
+}
+
+// Returns how close each pixel is to the center, 0 in the corners and 1 in the middle
+func (rg *RadialGradient) positions() []float64 {
+	w, h := rg.Rect.Dx(), rg.Rect.Dy()
+	cx, cy := float64(w)/2, float64(h)/2
+	// distances are measured from pixel centers, so the corner pixels are the furthest
+	maxR := max(math.Hypot(cx-0.5, cy-0.5), 1)
+	pos := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			d := math.Hypot(float64(x)+0.5-cx, float64(y)+0.5-cy)
+			pos[y*w+x] = 1 - d/maxR
+		}
+	}
+	return pos
+}
+
+// Renders a frame with middle's ring shift of the way out from the center.  Like
+// the stops of LinearGradient, outer is a ring behind it and inner a ring ahead.
+func (rg *RadialGradient) frame(outer *color.RGBA, middle *color.RGBA, inner *color.RGBA, pos []float64, shift float64) *image.RGBA {
+	img, ok := rg.frames.Get().(*image.RGBA)
+	if !ok || img.Rect.Dx() != rg.Rect.Dx() || img.Rect.Dy() != rg.Rect.Dy() {
+		img = image.NewRGBA(image.Rect(0, 0, rg.Rect.Dx(), rg.Rect.Dy()))
+	}
+	o, m, i := toRGBAf(outer), toRGBAf(middle), toRGBAf(inner)
+	for p, v := range pos {
+		col := rg.MixSpace.mix(o, m, clamp01(v+shift))
+		col = rg.MixSpace.mix(col, i, clamp01(v+shift-1))
+		c := col.quantize(0)
+		img.Pix[p*4], img.Pix[p*4+1], img.Pix[p*4+2], img.Pix[p*4+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func (rg *RadialGradient) Bounds() image.Rectangle {
+	return rg.Rect
+}
+
+func (rg *RadialGradient) PixelFormat() string {
+	return PixelFormat
+}
+
+func clamp01(v float64) float64 {
+	return min(max(v, 0), 1)
+}
@@ -0,0 +1,48 @@
+package frame
+
+import (
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+func TestRadialGradient(t *testing.T) {
+	width, height := 9, 7
+	rg := &RadialGradient{
+		ColorChannel: colorChannel(testColors[:4]...),
+		Transition:   3,
+		Rect:         image.Rect(0, 0, width, height),
+	}
+	rg.Reset()
+	go rg.Run()
+	defer rg.Close()
+	frameSize := width * height * 4
+	pixel := func(frame []byte, x int, y int) color.RGBA {
+		i := (y*width + x) * 4
+		return color.RGBA{frame[i], frame[i+1], frame[i+2], frame[i+3]}
+	}
+	frames := make([][]byte, 4)
+	for i := range frames {
+		frames[i] = make([]byte, frameSize)
+		if _, err := io.ReadFull(rg, frames[i]); err != nil {
+			t.Fatalf("reading frame %d: %s", i, err)
+		}
+	}
+	// the first frame fades from the second color in the middle to the first in the corners
+	center, corner := pixel(frames[0], width/2, height/2), pixel(frames[0], 0, 0)
+	if center == corner {
+		t.Errorf("center and corner are both %v", center)
+	}
+	if corner != testColors[0] {
+		t.Errorf("corner is %v, want %v", corner, testColors[0])
+	}
+	// after a transition the third color has pulsed out to the center
+	if got := pixel(frames[3], width/2, height/2); got != testColors[2] {
+		t.Errorf("center after a transition is %v, want %v", got, testColors[2])
+	}
+	// the rings are round, so pixels the same distance from the center match
+	if a, b := pixel(frames[1], 0, height/2), pixel(frames[1], width-1, height/2); a != b {
+		t.Errorf("left and right edges differ, %v and %v", a, b)
+	}
+}
@@ -0,0 +1,136 @@
+package frame
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"sync"
+)
+
+var (
+	ErrRawDumpMagic = errors.New("not a raw frame dump")
+	ErrFrameIndex   = errors.New("frame index out of range")
+	ErrRawDumpSize  = errors.New("invalid frame size")
+	ErrRawDumpClose = errors.New("raw dump is closed")
+
+	rawDumpMagic = [4]byte{'C', 'R', 'R', 'F'}
+)
+
+// Size in bytes of the raw dump header
+const rawDumpHeaderSize = 20
+
+// Header of a raw frame dump.  All values are little endian uint32s following the magic bytes.
+type RawDumpHeader struct {
+	Width  uint32
+	Height uint32
+	Frames uint32
+	FPS    uint32
+}
+
+func (h *RawDumpHeader) frameSize() int64 {
+	return int64(h.Width) * int64(h.Height) * 4
+}
+
+// Writes RGBA frames into a single file with a small header so individual frames can be seeked to.
+// The frame count in the header is filled in on Close.  Writes after Close are rejected,
+// so it is safe to close while another goroutine is still writing.
+type RawDumpWriter struct {
+	mu     sync.Mutex
+	closed bool
+	w      io.WriteSeeker
+	header RawDumpHeader
+	// bytes written past the header
+	written int64
+}
+
+func NewRawDumpWriter(w io.WriteSeeker, width int, height int, fps int) (*RawDumpWriter, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrRawDumpSize, width, height)
+	}
+	rdw := &RawDumpWriter{
+		w: w,
+		header: RawDumpHeader{
+			Width:  uint32(width),
+			Height: uint32(height),
+			FPS:    uint32(fps),
+		},
+	}
+	if _, err := w.Write(rawDumpMagic[:]); err != nil {
+		return nil, fmt.Errorf("writing magic: %w", err)
+	}
+	if err := binary.Write(w, binary.LittleEndian, &rdw.header); err != nil {
+		return nil, fmt.Errorf("writing header: %w", err)
+	}
+	return rdw, nil
+}
+
+func (rdw *RawDumpWriter) Write(b []byte) (int, error) {
+	rdw.mu.Lock()
+	defer rdw.mu.Unlock()
+	if rdw.closed {
+		return 0, ErrRawDumpClose
+	}
+	n, err := rdw.w.Write(b)
+	rdw.written += int64(n)
+	return n, err
+}
+
+// Updates the frame count in the header.  Partially written frames are not counted.
+func (rdw *RawDumpWriter) Close() error {
+	rdw.mu.Lock()
+	defer rdw.mu.Unlock()
+	if rdw.closed {
+		return nil
+	}
+	rdw.closed = true
+	rdw.header.Frames = uint32(rdw.written / rdw.header.frameSize())
+	if _, err := rdw.w.Seek(int64(len(rawDumpMagic)), io.SeekStart); err != nil {
+		return fmt.Errorf("seeking to header: %w", err)
+	}
+	if err := binary.Write(rdw.w, binary.LittleEndian, &rdw.header); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	if _, err := rdw.w.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("seeking to end: %w", err)
+	}
+	return nil
+}
+
+// Reads frames from a file written by RawDumpWriter
+type RawDumpReader struct {
+	RawDumpHeader
+	r io.ReadSeeker
+}
+
+func NewRawDumpReader(r io.ReadSeeker) (*RawDumpReader, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, fmt.Errorf("reading magic: %w", err)
+	}
+	if magic != rawDumpMagic {
+		return nil, ErrRawDumpMagic
+	}
+	rdr := &RawDumpReader{r: r}
+	if err := binary.Read(r, binary.LittleEndian, &rdr.RawDumpHeader); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	return rdr, nil
+}
+
+// Returns the frame at the given index
+func (rdr *RawDumpReader) Frame(idx int) (*image.RGBA, error) {
+	if idx < 0 || idx >= int(rdr.Frames) {
+		return nil, fmt.Errorf("%w: %d", ErrFrameIndex, idx)
+	}
+	size := rdr.frameSize()
+	if _, err := rdr.r.Seek(rawDumpHeaderSize+int64(idx)*size, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seeking to frame: %w", err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, int(rdr.Width), int(rdr.Height)))
+	if _, err := io.ReadFull(rdr.r, img.Pix); err != nil {
+		return nil, fmt.Errorf("reading frame: %w", err)
+	}
+	return img, nil
+}
@@ -0,0 +1,86 @@
+package frame
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRawDumpRoundTrip(t *testing.T) {
+	width, height, fps := 3, 2, 30
+	f, err := os.Create(filepath.Join(t.TempDir(), "dump.raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := NewRawDumpWriter(f, width, height, fps)
+	if err != nil {
+		t.Fatalf("creating writer: %s", err)
+	}
+	frames := make([]*image.RGBA, 4)
+	for i := range frames {
+		frames[i] = image.NewRGBA(image.Rect(0, 0, width, height))
+		for p := range frames[i].Pix {
+			frames[i].Pix[p] = uint8(i*50 + p)
+		}
+		// write in uneven chunks like a tee would
+		pix := frames[i].Pix
+		if _, err := w.Write(pix[:5]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(pix[5:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a partial frame isn't counted
+	if _, err := w.Write([]byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("closing writer: %s", err)
+	}
+	if _, err := w.Write([]byte{1}); !errors.Is(err, ErrRawDumpClose) {
+		t.Errorf("write after close returned %v, want %v", err, ErrRawDumpClose)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRawDumpReader(f)
+	if err != nil {
+		t.Fatalf("creating reader: %s", err)
+	}
+	want := RawDumpHeader{Width: uint32(width), Height: uint32(height), Frames: uint32(len(frames)), FPS: uint32(fps)}
+	if r.RawDumpHeader != want {
+		t.Errorf("header %+v, want %+v", r.RawDumpHeader, want)
+	}
+	// read out of order to check seeking
+	for _, i := range []int{2, 0, 3, 1} {
+		img, err := r.Frame(i)
+		if err != nil {
+			t.Fatalf("reading frame %d: %s", i, err)
+		}
+		if img.Rect != frames[i].Rect {
+			t.Errorf("frame %d is %v, want %v", i, img.Rect, frames[i].Rect)
+		}
+		if !bytes.Equal(img.Pix, frames[i].Pix) {
+			t.Errorf("frame %d pixels differ", i)
+		}
+	}
+	if _, err := r.Frame(len(frames)); !errors.Is(err, ErrFrameIndex) {
+		t.Errorf("reading past the end returned %v, want %v", err, ErrFrameIndex)
+	}
+}
+
+func TestRawDumpInvalid(t *testing.T) {
+	if _, err := NewRawDumpWriter(nil, 0, 10, 30); !errors.Is(err, ErrRawDumpSize) {
+		t.Errorf("zero width returned %v, want %v", err, ErrRawDumpSize)
+	}
+	if _, err := NewRawDumpReader(bytes.NewReader([]byte("nope and some more bytes"))); !errors.Is(err, ErrRawDumpMagic) {
+		t.Errorf("bad magic returned %v, want %v", err, ErrRawDumpMagic)
+	}
+}
@@ -0,0 +1,31 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+var ErrROI = errors.New("invalid region of interest")
+
+// Parses a region written as x,y,width,height
+func ParseROI(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("%w: %q, want x,y,width,height", ErrROI, s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("%w: %q: %w", ErrROI, s, err)
+		}
+		v[i] = n
+	}
+	if v[2] < 1 || v[3] < 1 {
+		return image.Rectangle{}, fmt.Errorf("%w: %q, the size must be positive", ErrROI, s)
+	}
+	return image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]), nil
+}
@@ -0,0 +1,24 @@
+package frame
+
+import (
+	"context"
+	"io"
+)
+
+// Runs the frame maker until it finishes or the context is done, in which case it's
+// closed so its reader hits io.EOF once the buffered frames are read
+func RunContext(ctx context.Context, m interface {
+	io.Closer
+	Run()
+}) {
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.Close()
+		case <-finished:
+		}
+	}()
+	m.Run()
+}
@@ -0,0 +1,182 @@
+package frame
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"io"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Returns a channel the test colors are sent on over and over until the test ends
+func feedColors(t *testing.T) chan *color.RGBA {
+	ch := make(chan *color.RGBA)
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case ch <- &testColors[i%len(testColors)]:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Returns one of each maker, 4 pixels wide, reading from colors that never run out
+func endlessMakers(t *testing.T) map[string]Maker {
+	return newMakers(func() chan *color.RGBA { return feedColors(t) })
+}
+
+// Returns one of each maker, 4 pixels wide, each reading from its own channel of colors
+func newMakers(colors func() chan *color.RGBA) map[string]Maker {
+	return map[string]Maker{
+		"linear": &LinearGradient{
+			ColorChannel: colors(),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, 4, 1),
+		},
+		"transition": &LinearGradientTransition{
+			ColorChannel: colors(),
+			Transition:   4,
+			ImageWidth:   4,
+			ImageHeight:  1,
+		},
+		"radial": &RadialGradient{
+			ColorChannel: colors(),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, 4, 1),
+		},
+		"multistop": &MultiStopGradient{
+			ColorChannel: colors(),
+			Transition:   4,
+			Rect:         image.Rect(0, 0, 4, 1),
+			Stops:        3,
+		},
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	for name, m := range endlessMakers(t) {
+		t.Run(name, func(t *testing.T) {
+			base := runtime.NumGoroutine()
+			m.Reset()
+			ctx, cancel := context.WithCancel(context.Background())
+			returned := make(chan struct{})
+			go func() {
+				RunContext(ctx, m)
+				close(returned)
+			}()
+			buf := make([]byte, 16)
+			if _, err := io.ReadFull(m, buf); err != nil {
+				t.Fatalf("reading a frame: %s", err)
+			}
+			// the reader stops here, so Run is left blocked on a full frame buffer
+			cancel()
+			select {
+			case <-returned:
+			case <-time.After(2 * time.Second):
+				t.Fatal("Run didn't return after the context was cancelled")
+			}
+			if _, err := io.Copy(io.Discard, m); err != nil {
+				t.Errorf("draining the buffered frames: %s", err)
+			}
+			if n, err := m.Read(buf); n != 0 || err != io.EOF {
+				t.Errorf("got %d, %v after the context was cancelled, want io.EOF", n, err)
+			}
+			waitForGoroutines(t, base)
+		})
+	}
+}
+
+func TestRunContextWhileBlocked(t *testing.T) {
+	// waiting for a color which never comes, and paused
+	blocked := map[string]map[string]Maker{
+		"starved": newMakers(func() chan *color.RGBA { return make(chan *color.RGBA) }),
+		"paused":  endlessMakers(t),
+	}
+	for state, makers := range blocked {
+		for name, m := range makers {
+			t.Run(state+"/"+name, func(t *testing.T) {
+				m.Reset()
+				if state == "paused" {
+					m.Pause()
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				returned := make(chan struct{})
+				go func() {
+					RunContext(ctx, m)
+					close(returned)
+				}()
+				time.Sleep(10 * time.Millisecond)
+				cancel()
+				select {
+				case <-returned:
+				case <-time.After(2 * time.Second):
+					t.Fatal("Run didn't return after the context was cancelled")
+				}
+				if _, err := io.Copy(io.Discard, m); err != nil {
+					t.Errorf("reading after the context was cancelled: %s", err)
+				}
+			})
+		}
+	}
+}
+
+func TestPause(t *testing.T) {
+	for name, m := range endlessMakers(t) {
+		t.Run(name, func(t *testing.T) {
+			m.Reset()
+			go m.Run()
+			var frames atomic.Int64
+			read := make(chan struct{})
+			go func() {
+				defer close(read)
+				buf := make([]byte, 16)
+				for {
+					if _, err := io.ReadFull(m, buf); err != nil {
+						return
+					}
+					frames.Add(1)
+				}
+			}()
+			// waits up to a second for frames to be read
+			flowing := func() bool {
+				start := frames.Load()
+				deadline := time.Now().Add(time.Second)
+				for frames.Load() == start && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+				return frames.Load() > start
+			}
+			if !flowing() {
+				t.Fatal("no frames before pausing")
+			}
+			m.Pause()
+			// the frames already buffered are read
+			time.Sleep(50 * time.Millisecond)
+			paused := frames.Load()
+			time.Sleep(100 * time.Millisecond)
+			if n := frames.Load(); n != paused {
+				t.Errorf("read %d frames while paused", n-paused)
+			}
+			m.Resume()
+			if !flowing() {
+				t.Error("no frames after resuming")
+			}
+			// closing while paused doesn't leave Run waiting
+			m.Pause()
+			m.Close()
+			select {
+			case <-read:
+			case <-time.After(2 * time.Second):
+				t.Fatal("Read didn't hit EOF after closing a paused maker")
+			}
+		})
+	}
+}
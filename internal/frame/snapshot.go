@@ -0,0 +1,85 @@
+package frame
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"sync"
+
+	imgenc "github.com/broganross/color-run/internal/image"
+	"github.com/rs/zerolog/log"
+)
+
+// Keeps a copy of the last whole frame written to it, so the stream can be looked at
+// without dumping every frame
+type Snapshot struct {
+	mu    sync.Mutex
+	rect  image.Rectangle
+	frame []byte
+	last  []byte
+}
+
+func NewSnapshot(width int, height int) *Snapshot {
+	return &Snapshot{
+		rect:  image.Rect(0, 0, width, height),
+		frame: make([]byte, 0, width*height*4),
+	}
+}
+
+func (s *Snapshot) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(b)
+	for len(b) > 0 {
+		take := min(len(b), cap(s.frame)-len(s.frame))
+		s.frame = append(s.frame, b[:take]...)
+		b = b[take:]
+		if len(s.frame) == cap(s.frame) {
+			// the old last frame becomes the buffer for the next one
+			s.last, s.frame = s.frame, s.last[:0]
+			if s.frame == nil {
+				s.frame = make([]byte, 0, cap(s.last))
+			}
+		}
+	}
+	return n, nil
+}
+
+// Returns a copy of the last whole frame, or nil before there's been one
+func (s *Snapshot) Frame() *image.RGBA {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.last == nil {
+		return nil
+	}
+	img := image.NewRGBA(s.rect)
+	copy(img.Pix, s.last)
+	return img
+}
+
+var snapshotEncoder = imgenc.PNGEncoder(png.DefaultCompression)
+
+// Serves the last frame as a PNG, or 503 before there's been one
+func (s *Snapshot) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	img := s.Frame()
+	if img == nil {
+		http.Error(w, "no frame yet", http.StatusServiceUnavailable)
+		return
+	}
+	// encoded up front so a failure can still be reported with a status
+	buf := bytes.Buffer{}
+	if err := snapshotEncoder(&buf, img); err != nil {
+		log.Error().Err(err).Msg("encoding snapshot")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Error().Err(err).Msg("writing snapshot response")
+	}
+}
@@ -0,0 +1,58 @@
+package frame
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	width, height := 8, 2
+	snap := NewSnapshot(width, height)
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		snap.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/snapshot", nil))
+		return rec
+	}
+	if rec := get(); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("before any frames got status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	lg := &LinearGradient{
+		ColorChannel: colorChannel(testColors...),
+		Transition:   4,
+		Rect:         image.Rect(0, 0, width, height),
+	}
+	lg.Reset()
+	go lg.Run()
+	// odd sized reads so frames span writes
+	out := readInChunks(t, io.TeeReader(lg, snap), 7)
+	frameSize := width * height * 4
+	want := out[len(out)-frameSize:]
+
+	rec := get()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("got content type %q", ct)
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatalf("decoding snapshot: %s", err)
+	}
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		t.Fatalf("decoded a %T", img)
+	}
+	if rgba.Rect.Dx() != width || rgba.Rect.Dy() != height {
+		t.Fatalf("got a %v snapshot", rgba.Rect)
+	}
+	if !bytes.Equal(rgba.Pix, want) {
+		t.Errorf("snapshot isn't the last frame")
+	}
+}
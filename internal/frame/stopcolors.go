@@ -0,0 +1,38 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/broganross/color-run/internal/colormind"
+)
+
+var ErrStopColor = errors.New("invalid stop color")
+
+// Parses stop colors written as stop=#rrggbb, ie. 1=#ff8800 for the middle stop, into a
+// palette and the StopColors mapping into it
+func ParseStopColors(specs []string) ([]*color.RGBA, map[int]int, error) {
+	palette := []*color.RGBA{}
+	stops := map[int]int{}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		stopStr, hex, ok := strings.Cut(spec, "=")
+		stop, err := strconv.Atoi(stopStr)
+		if !ok || err != nil || stop < 0 || stop > 2 {
+			return nil, nil, fmt.Errorf("%w: %q, the stop must be 0, 1 or 2", ErrStopColor, spec)
+		}
+		c, err := colormind.ParseHexColor(hex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %q: %w", ErrStopColor, spec, err)
+		}
+		stops[stop] = len(palette)
+		palette = append(palette, (*color.RGBA)(c))
+	}
+	return palette, stops, nil
+}
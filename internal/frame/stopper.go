@@ -0,0 +1,80 @@
+package frame
+
+import "sync"
+
+// Lets the reader of a frame maker tell Run it has gone away, or to hold off for a while
+type stopper struct {
+	mu   sync.Mutex
+	done chan struct{}
+	// open while paused, nil while running
+	paused chan struct{}
+}
+
+// Returns a channel which is closed once Close has been called
+func (s *stopper) stopped() chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	return s.done
+}
+
+// Stops Run, which closes the frame buffer so Read returns io.EOF once the
+// buffered frames have been read.  Safe to call more than once.
+func (s *stopper) Close() error {
+	done := s.stopped()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-done:
+	default:
+		close(done)
+	}
+	return nil
+}
+
+// Holds Run before it renders its next frame until Resume is called.  The frames
+// already buffered can still be read, after which Read blocks.  Nothing is dropped,
+// so the colors carry on where they left off.
+func (s *stopper) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused == nil {
+		s.paused = make(chan struct{})
+	}
+}
+
+// Lets a paused Run carry on.  Safe to call when it isn't paused.
+func (s *stopper) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused != nil {
+		close(s.paused)
+		s.paused = nil
+	}
+}
+
+// Blocks while paused.  Returns false if Close was called in the meantime.
+func (s *stopper) wait() bool {
+	s.mu.Lock()
+	paused := s.paused
+	s.mu.Unlock()
+	if paused == nil {
+		return true
+	}
+	select {
+	case <-paused:
+		return true
+	case <-s.stopped():
+		return false
+	}
+}
+
+// Clears Close and Pause for the next Run
+func (s *stopper) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done = nil
+	s.paused = nil
+}
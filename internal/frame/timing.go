@@ -0,0 +1,29 @@
+package frame
+
+import (
+	"math"
+
+	"github.com/rs/zerolog"
+)
+
+// Number of frames a transition lasting the given seconds takes at the frame rate
+func TransitionFrames(seconds float64, fps int) int {
+	return int(math.Round(seconds * float64(fps)))
+}
+
+// Longest transition allowed.  One frame per pixel keeps the scrolling gradient moving
+// every frame, but short images may still take a second.
+func MaxTransition(width int, fps int) int {
+	return max(width, fps)
+}
+
+// Clamps the transition to MaxTransition, warning when it does.  Absurd values make
+// the colors change imperceptibly and the frame buffers sized from them huge.
+func ClampTransition(l *zerolog.Logger, frames int, width int, fps int) int {
+	limit := MaxTransition(width, fps)
+	if frames > limit {
+		l.Warn().Int("frames", frames).Int("max", limit).Msg("transition too long, clamping it")
+		return limit
+	}
+	return frames
+}
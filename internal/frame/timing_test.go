@@ -0,0 +1,61 @@
+package frame
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestTransitionFrames(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		fps     int
+		want    int
+	}{
+		{2, 30, 60},
+		{2, 60, 120},
+		{0.5, 25, 13},
+	}
+	for _, tt := range tests {
+		if got := TransitionFrames(tt.seconds, tt.fps); got != tt.want {
+			t.Errorf("%gs at %d fps: got %d frames, want %d", tt.seconds, tt.fps, got, tt.want)
+		}
+	}
+}
+
+func TestClampTransition(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := zerolog.New(buf)
+	width, fps := 8, 4
+	if got := ClampTransition(&l, 6, width, fps); got != 6 {
+		t.Errorf("in range transition changed to %d", got)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("warned about an in range transition: %s", buf)
+	}
+	got := ClampTransition(&l, 1_000_000_000, width, fps)
+	if got != width {
+		t.Errorf("got %d, want %d", got, width)
+	}
+	if !strings.Contains(buf.String(), `"level":"warn"`) {
+		t.Errorf("clamping didn't warn: %q", buf)
+	}
+	// every frame of the clamped scroll moves, rather than repeating the last one
+	lg := &LinearGradient{
+		ColorChannel: colorChannel(testColors...),
+		Transition:   got,
+		Rect:         image.Rect(0, 0, width, 1),
+	}
+	lg.Reset()
+	go lg.Run()
+	frames := readInChunks(t, lg, 4096)
+	frameSize := width * 4
+	for i := frameSize; i+frameSize <= len(frames); i += frameSize {
+		if bytes.Equal(frames[i-frameSize:i], frames[i:i+frameSize]) {
+			t.Fatalf("frame %d repeats the one before it", i/frameSize)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package image
+
+import (
+	"bytes"
+	stdimage "image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+)
+
+// A gradient with a different color in every pixel
+func testImage() *stdimage.RGBA {
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x * 16), G: uint8(y * 32), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// Largest difference between any channel of the images' pixels
+func maxDiff(a stdimage.Image, b stdimage.Image) int {
+	most := 0
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			ca := color.RGBAModel.Convert(a.At(x, y)).(color.RGBA)
+			cb := color.RGBAModel.Convert(b.At(x, y)).(color.RGBA)
+			for _, d := range []int{int(ca.R) - int(cb.R), int(ca.G) - int(cb.G), int(ca.B) - int(cb.B), int(ca.A) - int(cb.A)} {
+				most = max(most, d, -d)
+			}
+		}
+	}
+	return most
+}
+
+func TestEncoders(t *testing.T) {
+	want := testImage()
+	tests := map[string]struct {
+		encode Encoder
+		decode func(r io.Reader) (stdimage.Image, error)
+		// largest difference allowed in any channel
+		tolerance int
+	}{
+		"png":       {PNGEncoder(png.DefaultCompression), png.Decode, 0},
+		"png-none":  {PNGEncoder(png.NoCompression), png.Decode, 0},
+		"png-speed": {PNGEncoder(png.BestSpeed), png.Decode, 0},
+		"png-best":  {PNGEncoder(png.BestCompression), png.Decode, 0},
+		"jpeg":      {JPEGEncoder(95), jpeg.Decode, 24},
+		"raw": {RawRGBAEncoder, func(r io.Reader) (stdimage.Image, error) {
+			img := stdimage.NewRGBA(want.Rect)
+			_, err := io.ReadFull(r, img.Pix)
+			return img, err
+		}, 0},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := test.encode(buf, want); err != nil {
+				t.Fatalf("encoding: %s", err)
+			}
+			got, err := test.decode(buf)
+			if err != nil {
+				t.Fatalf("decoding: %s", err)
+			}
+			if got.Bounds() != want.Bounds() {
+				t.Fatalf("decoded bounds %v, want %v", got.Bounds(), want.Bounds())
+			}
+			if d := maxDiff(got, want); d > test.tolerance {
+				t.Errorf("channels differ by up to %d, want at most %d", d, test.tolerance)
+			}
+		})
+	}
+}
+
+func TestRawRGBAEncoderSubImage(t *testing.T) {
+	img := testImage()
+	sub := img.SubImage(stdimage.Rect(4, 2, 8, 4))
+	buf := &bytes.Buffer{}
+	if err := RawRGBAEncoder(buf, sub); err != nil {
+		t.Fatalf("encoding: %s", err)
+	}
+	var want []byte
+	for y := 2; y < 4; y++ {
+		want = append(want, img.Pix[img.PixOffset(4, y):img.PixOffset(8, y)]...)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("got % x, want % x", buf.Bytes(), want)
+	}
+	// other image types are converted
+	gray := stdimage.NewGray(stdimage.Rect(0, 0, 2, 1))
+	gray.Pix = []byte{10, 200}
+	buf.Reset()
+	if err := RawRGBAEncoder(buf, gray); err != nil {
+		t.Fatalf("encoding gray: %s", err)
+	}
+	if want := []byte{10, 10, 10, 255, 200, 200, 200, 255}; !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("got % x for gray, want % x", buf.Bytes(), want)
+	}
+}
+
+func BenchmarkPNGEncoder(b *testing.B) {
+	// a full HD frame of a smooth gradient, as the makers draw
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, 1920, 1080))
+	for y := 0; y < 1080; y++ {
+		for x := 0; x < 1920; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x * 255 / 1919), G: uint8(y * 255 / 1079), B: 128, A: 255})
+		}
+	}
+	for _, name := range []string{"none", "speed", "default", "best"} {
+		b.Run(name, func(b *testing.B) {
+			level, _ := ParsePNGCompression(name)
+			encode := PNGEncoder(level)
+			buf := &bytes.Buffer{}
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := encode(buf, img); err != nil {
+					b.Fatalf("encoding: %s", err)
+				}
+			}
+			b.ReportMetric(float64(buf.Len()), "bytes/frame")
+		})
+	}
+}
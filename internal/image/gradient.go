@@ -0,0 +1,55 @@
+package image
+
+import (
+	"errors"
+	"fmt"
+	stdimage "image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+var ErrGradient = errors.New("invalid gradient")
+
+// Writes a PNG of the colors spread evenly across width pixels, mixed in sRGB, ie. a
+// swatch of the palette being streamed.  The first and last pixels are the first and
+// last colors.  One color fills the image.  w isn't closed.
+func WriteGradientPNG(colors []*color.RGBA, width int, height int, w io.Writer) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%w: size %dx%d", ErrGradient, width, height)
+	}
+	if len(colors) == 0 {
+		return fmt.Errorf("%w: no colors", ErrGradient)
+	}
+	for i, c := range colors {
+		if c == nil {
+			return fmt.Errorf("%w: color %d is nil", ErrGradient, i)
+		}
+	}
+	img := stdimage.NewRGBA(stdimage.Rect(0, 0, width, height))
+	row := img.Pix[:width*4]
+	segments := len(colors) - 1
+	span := max(width-1, 1)
+	for x := 0; x < width; x++ {
+		// found in integers so the last pixel lands on the last color exactly
+		k := x * segments / span
+		c := *colors[min(k, segments)]
+		if k < segments {
+			t := float64(x*segments%span) / float64(span)
+			c = mix(colors[k], colors[k+1], t)
+		}
+		row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = c.R, c.G, c.B, c.A
+	}
+	for y := 1; y < height; y++ {
+		copy(img.Pix[y*img.Stride:], row)
+	}
+	return PNGEncoder(png.DefaultCompression)(w, img)
+}
+
+// Mixes the channels of a and b, rounding to the nearest
+func mix(a *color.RGBA, b *color.RGBA, t float64) color.RGBA {
+	ch := func(x uint8, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return color.RGBA{R: ch(a.R, b.R), G: ch(a.G, b.G), B: ch(a.B, b.B), A: ch(a.A, b.A)}
+}
@@ -0,0 +1,64 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestWriteGradientPNG(t *testing.T) {
+	colors := []*color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+		{R: 255, G: 255, A: 255},
+		{G: 255, B: 255, A: 255},
+	}
+	for _, width := range []int{1, 2, 5, 7, 64, 101} {
+		buf := &bytes.Buffer{}
+		if err := WriteGradientPNG(colors, width, 3, buf); err != nil {
+			t.Fatalf("width %d: writing: %s", width, err)
+		}
+		img, err := png.Decode(buf)
+		if err != nil {
+			t.Fatalf("width %d: decoding: %s", width, err)
+		}
+		if got := img.Bounds().Size(); got.X != width || got.Y != 3 {
+			t.Errorf("width %d: got size %v", width, got)
+		}
+		left := color.RGBAModel.Convert(img.At(0, 2))
+		if left != *colors[0] {
+			t.Errorf("width %d: leftmost pixel is %v, want %v", width, left, *colors[0])
+		}
+		if width < 2 {
+			continue
+		}
+		right := color.RGBAModel.Convert(img.At(width-1, 2))
+		if want := *colors[len(colors)-1]; right != want {
+			t.Errorf("width %d: rightmost pixel is %v, want %v", width, right, want)
+		}
+	}
+	// the middle of 3 pixels over 2 colors is halfway between them
+	buf := &bytes.Buffer{}
+	if err := WriteGradientPNG([]*color.RGBA{{A: 255}, {R: 200, A: 255}}, 3, 1, buf); err != nil {
+		t.Fatal(err)
+	}
+	img, _ := png.Decode(buf)
+	if got, want := color.RGBAModel.Convert(img.At(1, 0)), (color.RGBA{R: 100, A: 255}); got != want {
+		t.Errorf("middle pixel is %v, want %v", got, want)
+	}
+	for _, bad := range []struct {
+		colors []*color.RGBA
+		width  int
+	}{
+		{nil, 4},
+		{[]*color.RGBA{nil}, 4},
+		{colors, 0},
+	} {
+		if err := WriteGradientPNG(bad.colors, bad.width, 1, &bytes.Buffer{}); !errors.Is(err, ErrGradient) {
+			t.Errorf("got %v for %d colors %d wide, want %v", err, len(bad.colors), bad.width, ErrGradient)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+var ErrFormat = errors.New("unknown log format")
+
+const (
+	FormatAuto    = "auto"
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Resolves the auto format to console when writing to a terminal and json otherwise.
+// Any other known format is returned as is.
+func Format(format string, isTerminal bool) (string, error) {
+	switch format {
+	case FormatAuto:
+		if isTerminal {
+			return FormatConsole, nil
+		}
+		return FormatJSON, nil
+	case FormatConsole, FormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrFormat, format)
+}
+
+// Returns a writer emitting log events in the resolved format
+func Writer(format string, w io.Writer) io.Writer {
+	if format == FormatConsole {
+		return zerolog.ConsoleWriter{Out: w}
+	}
+	return w
+}
@@ -0,0 +1,31 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		format     string
+		isTerminal bool
+		want       string
+	}{
+		{FormatAuto, true, FormatConsole},
+		{FormatAuto, false, FormatJSON},
+		{FormatJSON, true, FormatJSON},
+		{FormatConsole, false, FormatConsole},
+	}
+	for _, tt := range tests {
+		got, err := Format(tt.format, tt.isTerminal)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.format, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s with terminal %t: got %s, want %s", tt.format, tt.isTerminal, got, tt.want)
+		}
+	}
+	if _, err := Format("xml", true); !errors.Is(err, ErrFormat) {
+		t.Errorf("got %v, want %v", err, ErrFormat)
+	}
+}
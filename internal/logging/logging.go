@@ -0,0 +1,15 @@
+package logging
+
+import "github.com/rs/zerolog"
+
+// Returns a logger which only emits 1 in n trace and debug messages.  Warnings and
+// errors are never sampled.  Values of n below 2 return the logger unchanged.
+func Sample(l zerolog.Logger, n int) zerolog.Logger {
+	if n < 2 {
+		return l
+	}
+	return l.Sample(zerolog.LevelSampler{
+		TraceSampler: &zerolog.BasicSampler{N: uint32(n)},
+		DebugSampler: &zerolog.BasicSampler{N: uint32(n)},
+	})
+}
@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestSample(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		debug  int
+		warn   int
+		output int
+	}{
+		{"disabled", 0, 100, 0, 100},
+		{"one", 1, 100, 0, 100},
+		{"every tenth", 10, 100, 0, 10},
+		{"warnings unsampled", 10, 0, 100, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			l := Sample(zerolog.New(buf).Level(zerolog.TraceLevel), tt.n)
+			for i := 0; i < tt.debug; i++ {
+				l.Debug().Msg("frame")
+			}
+			for i := 0; i < tt.warn; i++ {
+				l.Warn().Msg("frame")
+			}
+			if got := strings.Count(buf.String(), "\n"); got != tt.output {
+				t.Errorf("got %d messages, want %d", got, tt.output)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Wraps Sink, logging every Interval how far the frames it's given have drifted from
+// real time.  A frame's presentation time is its index over the frame rate, so frames
+// arriving slower than that put the stream behind the wall clock (positive drift),
+// where a real time consumer has to duplicate frames, and faster puts it ahead, where
+// it drops them.
+type DriftMonitor struct {
+	Sink      Sink
+	FrameRate int
+	FrameSize int
+	Interval  time.Duration
+	// Defaults to the global logger
+	Logger *zerolog.Logger
+}
+
+// Where the frames stood against the wall clock at one moment
+type DriftReport struct {
+	Frames int
+	// Wall clock time since the first frame less the presentation time of the frames read
+	Drift time.Duration
+	// Whole frames the stream is behind
+	Duplicated int
+	// Whole frames the stream is ahead
+	Dropped int
+}
+
+func (dm *DriftMonitor) Run(ctx context.Context, frames io.Reader) error {
+	logger := dm.Logger
+	if logger == nil {
+		logger = &log.Logger
+	}
+	dr := &driftReader{r: frames}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(dm.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				dm.log(logger, dr, time.Now())
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := dm.Sink.Run(ctx, dr)
+	close(done)
+	<-finished
+	dm.log(logger, dr, time.Now())
+	return err
+}
+
+func (dm *DriftMonitor) log(l *zerolog.Logger, dr *driftReader, now time.Time) {
+	start, read := dr.progress()
+	if start.IsZero() {
+		return
+	}
+	r := dm.report(start, read, now)
+	l.Info().Str("event", "drift").Int("frames", r.Frames).Dur("drift", r.Drift).Int("duplicated", r.Duplicated).Int("dropped", r.Dropped).Send()
+}
+
+// Compares the frames in the bytes read since start to the wall clock at now
+func (dm *DriftMonitor) report(start time.Time, read int64, now time.Time) DriftReport {
+	r := DriftReport{Frames: int(read / int64(dm.FrameSize))}
+	pts := time.Duration(r.Frames) * time.Second / time.Duration(dm.FrameRate)
+	r.Drift = now.Sub(start) - pts
+	behind := int(r.Drift * time.Duration(dm.FrameRate) / time.Second)
+	if behind > 0 {
+		r.Duplicated = behind
+	} else {
+		r.Dropped = -behind
+	}
+	return r
+}
+
+// Records when frames started being read and how many bytes have been
+type driftReader struct {
+	r     io.Reader
+	mu    sync.Mutex
+	start time.Time
+	read  int64
+}
+
+func (dr *driftReader) Read(b []byte) (int, error) {
+	dr.mu.Lock()
+	if dr.start.IsZero() {
+		dr.start = time.Now()
+	}
+	dr.mu.Unlock()
+	n, err := dr.r.Read(b)
+	dr.mu.Lock()
+	dr.read += int64(n)
+	dr.mu.Unlock()
+	return n, err
+}
+
+func (dr *driftReader) progress() (time.Time, int64) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	return dr.start, dr.read
+}
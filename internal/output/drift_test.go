@@ -0,0 +1,90 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Returns a frame per read, after a delay
+type slowFrames struct {
+	frameSize int
+	frames    int
+	delay     time.Duration
+}
+
+func (sf *slowFrames) Read(b []byte) (int, error) {
+	if sf.frames == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(sf.delay)
+	sf.frames--
+	n := min(len(b), sf.frameSize)
+	clear(b[:n])
+	return n, nil
+}
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (lb *lockedBuffer) Write(p []byte) (int, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.buf.Write(p)
+}
+
+func TestDriftMonitor(t *testing.T) {
+	buf := &lockedBuffer{}
+	logger := zerolog.New(buf)
+	dm := &DriftMonitor{
+		Sink:      &Raw{W: io.Discard},
+		FrameRate: 100,
+		FrameSize: 4,
+		Interval:  20 * time.Millisecond,
+		Logger:    &logger,
+	}
+	// frames every 10ms would keep up, every 30ms falls behind
+	if err := dm.Run(context.Background(), &slowFrames{frameSize: 4, frames: 5, delay: 30 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.buf.String()), "\n")
+	last := struct {
+		Event      string  `json:"event"`
+		Frames     int     `json:"frames"`
+		Drift      float64 `json:"drift"`
+		Duplicated int     `json:"duplicated"`
+	}{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatal(err)
+	}
+	if last.Event != "drift" || last.Frames != 5 {
+		t.Fatalf("got final report %s, want a drift report of 5 frames", lines[len(lines)-1])
+	}
+	if last.Drift <= 0 || last.Duplicated < 1 {
+		t.Errorf("got drift %vms with %d duplicated frames, want the delay reported as positive drift", last.Drift, last.Duplicated)
+	}
+	if len(lines) < 2 {
+		t.Errorf("got %d reports, want periodic reports as well as the final one", len(lines))
+	}
+}
+
+func TestDriftReport(t *testing.T) {
+	dm := &DriftMonitor{FrameRate: 10, FrameSize: 4}
+	start := time.Unix(0, 0)
+	// 5 frames is half a second of video
+	if r := dm.report(start, 20, start.Add(time.Second)); r.Drift != 500*time.Millisecond || r.Duplicated != 5 || r.Dropped != 0 {
+		t.Errorf("behind: got %+v", r)
+	}
+	if r := dm.report(start, 80, start.Add(time.Second)); r.Drift != -time.Second || r.Dropped != 10 || r.Duplicated != 0 {
+		t.Errorf("ahead: got %+v", r)
+	}
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// How soon a sink has to fail for Failover to move on when Early isn't set
+const DefaultFailoverEarly = 10 * time.Second
+
+// Runs the first of Sinks, moving on to the next when one fails within Early of
+// starting, ie. when ffmpeg can't connect to an ingest server.  Frames read by the
+// failed sink are lost, the next starts from the next whole frame.  A sink failing
+// later, or the last one failing, ends the run with its error.
+type Failover struct {
+	Sinks []Sink
+	Early time.Duration
+	// Size of a frame in bytes
+	FrameSize int
+	// Defaults to the global logger
+	Logger *zerolog.Logger
+}
+
+func (f *Failover) Run(ctx context.Context, frames io.Reader) error {
+	logger := f.Logger
+	if logger == nil {
+		logger = &log.Logger
+	}
+	early := f.Early
+	if early <= 0 {
+		early = DefaultFailoverEarly
+	}
+	fa := &frameAligner{r: frames}
+	for i, sink := range f.Sinks {
+		start := time.Now()
+		err := sink.Run(ctx, fa)
+		if err == nil || ctx.Err() != nil || i == len(f.Sinks)-1 || time.Since(start) >= early {
+			return err
+		}
+		logger.Warn().Err(err).Int("sink", i).Msg("output failed as it started, trying the next")
+		if err := fa.align(f.FrameSize); err != nil {
+			return fmt.Errorf("skipping to the next frame: %w", err)
+		}
+	}
+	return nil
+}
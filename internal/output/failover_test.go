@@ -0,0 +1,67 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// Fails after running for a while, or returns nil when err is nil
+type failingSink struct {
+	after time.Duration
+	err   error
+	runs  int
+}
+
+func (fs *failingSink) Run(ctx context.Context, frames io.Reader) error {
+	fs.runs++
+	time.Sleep(fs.after)
+	return fs.err
+}
+
+func TestFailover(t *testing.T) {
+	refused := errors.New("connection refused")
+	dropped := errors.New("connection dropped")
+	tests := map[string]struct {
+		sinks []*failingSink
+		want  error
+		runs  []int
+	}{
+		"first works": {
+			sinks: []*failingSink{{}, {}},
+			runs:  []int{1, 0},
+		},
+		"fails early": {
+			sinks: []*failingSink{{err: refused}, {}},
+			runs:  []int{1, 1},
+		},
+		"fails late": {
+			sinks: []*failingSink{{after: 30 * time.Millisecond, err: dropped}, {}},
+			want:  dropped,
+			runs:  []int{1, 0},
+		},
+		"all fail": {
+			sinks: []*failingSink{{err: refused}, {err: refused}},
+			want:  refused,
+			runs:  []int{1, 1},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &Failover{Early: 20 * time.Millisecond}
+			for _, s := range test.sinks {
+				f.Sinks = append(f.Sinks, s)
+			}
+			if err := f.Run(context.Background(), nil); !errors.Is(err, test.want) {
+				t.Errorf("got %v, want %v", err, test.want)
+			}
+			for i, s := range test.sinks {
+				if s.runs != test.runs[i] {
+					t.Errorf("sink %d ran %d times, want %d", i, s.runs, test.runs[i])
+				}
+			}
+		})
+	}
+}
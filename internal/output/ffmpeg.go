@@ -0,0 +1,117 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	ffmpeg "github.com/u2takey/ffmpeg-go"
+)
+
+const (
+	DefaultVideoCodec   = "libx264"
+	DefaultVideoBitrate = "6000k"
+	DefaultPreset       = "veryfast"
+)
+
+// Encodes the frames with ffmpeg and sends them to URL
+type FFmpeg struct {
+	URL    string
+	Width  int
+	Height int
+	// Size the frames are scaled to before they're encoded, ie. when they're rendered
+	// smaller than the stream.  Left as they are when 0.
+	ScaleWidth  int
+	ScaleHeight int
+	// pix_fmt of the raw frames, rgba when empty
+	PixelFormat string
+	FrameRate   int
+	// Encoder options, DefaultVideoCodec, DefaultVideoBitrate and DefaultPreset when empty
+	VideoCodec   string
+	VideoBitrate string
+	Preset       string
+	// How long ffmpeg has to finish once the context is done before it's killed.
+	// 0 waits as long as it takes.
+	ShutdownTimeout time.Duration
+	// Extra output options, ie. ColorProfile.FFmpegArgs
+	ColorArgs map[string]string
+	// Matrix the frames are converted to YUV with, ie. ColorProfile.Matrix.  swscale's
+	// default, BT.601, when empty.
+	ColorMatrix string
+}
+
+func (f *FFmpeg) outputArgs() ffmpeg.KwArgs {
+	args := ffmpeg.KwArgs{
+		"framerate": f.FrameRate,
+		"c:v":       orDefault(f.VideoCodec, DefaultVideoCodec),
+		"b:v":       orDefault(f.VideoBitrate, DefaultVideoBitrate),
+		"preset":    orDefault(f.Preset, DefaultPreset),
+		"f":         "flv",
+	}
+	if vf := f.scaleFilter(); vf != "" {
+		args["vf"] = vf
+	}
+	for k, v := range f.ColorArgs {
+		args[k] = v
+	}
+	return args
+}
+
+// Returns the scale filter sizing the frames and converting them with ColorMatrix,
+// empty when neither is needed
+func (f *FFmpeg) scaleFilter() string {
+	var opts []string
+	if f.ScaleWidth > 0 && f.ScaleHeight > 0 && (f.ScaleWidth != f.Width || f.ScaleHeight != f.Height) {
+		opts = append(opts, strconv.Itoa(f.ScaleWidth), strconv.Itoa(f.ScaleHeight))
+	}
+	if f.ColorMatrix != "" {
+		opts = append(opts, "out_color_matrix="+f.ColorMatrix)
+	}
+	if len(opts) == 0 {
+		return ""
+	}
+	return "scale=" + strings.Join(opts, ":")
+}
+
+func orDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func (f *FFmpeg) inputArgs() ffmpeg.KwArgs {
+	pixFmt := f.PixelFormat
+	if pixFmt == "" {
+		pixFmt = "rgba"
+	}
+	return ffmpeg.KwArgs{
+		"f":          "rawvideo",
+		"pix_fmt":    pixFmt,
+		"video_size": fmt.Sprintf("%dx%d", f.Width, f.Height),
+	}
+}
+
+func (f *FFmpeg) Run(ctx context.Context, frames io.Reader) error {
+	proc := ffmpeg.
+		Input("pipe:0", f.inputArgs()).
+		WithInput(frames).
+		Output(f.URL, f.outputArgs()).
+		OverWriteOutput().
+		ErrorToStdOut().
+		Compile()
+	log.Info().Msg("waiting for ffmpeg")
+	err := runProcess(ctx, proc, f.ShutdownTimeout)
+	// ffmpeg has inconsitent exit codes, TODO: figure out a way to handle this so that we stop when ffmpeg fails
+	if proc.ProcessState != nil {
+		log.Info().Int("exit-code", proc.ProcessState.ExitCode()).Msg("ffmpeg exited")
+	}
+	if err != nil {
+		return fmt.Errorf("running ffmpeg: %w", err)
+	}
+	return nil
+}
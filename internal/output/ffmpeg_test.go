@@ -0,0 +1,27 @@
+package output
+
+import "testing"
+
+func TestFFmpegOutputArgs(t *testing.T) {
+	args := (&FFmpeg{FrameRate: 15, VideoCodec: "libx265", VideoBitrate: "2500k", Preset: "ultrafast"}).outputArgs()
+	want := map[string]any{"framerate": 15, "c:v": "libx265", "b:v": "2500k", "preset": "ultrafast"}
+	for k, v := range want {
+		if args[k] != v {
+			t.Errorf("ffmpeg %s is %v, want %v", k, args[k], v)
+		}
+	}
+	args = (&FFmpeg{FrameRate: 30}).outputArgs()
+	want = map[string]any{"c:v": DefaultVideoCodec, "b:v": DefaultVideoBitrate, "preset": DefaultPreset}
+	for k, v := range want {
+		if args[k] != v {
+			t.Errorf("ffmpeg %s defaults to %v, want %v", k, args[k], v)
+		}
+	}
+	if _, ok := args["vf"]; ok {
+		t.Errorf("scaling frames without a scaled size, %v", args["vf"])
+	}
+	args = (&FFmpeg{Width: 960, Height: 540, ScaleWidth: 1920, ScaleHeight: 1080}).outputArgs()
+	if args["vf"] != "scale=1920:1080" {
+		t.Errorf("ffmpeg vf is %v, want scale=1920:1080", args["vf"])
+	}
+}
@@ -0,0 +1,204 @@
+package output
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+var ErrFrameRate = errors.New("frame rate must be positive")
+
+// Compresses one raw RGBA frame into the body of an FLV video tag, starting with the
+// frame type and codec id byte.  Codecs needing a sequence header, like H.264, return
+// it as the body of the first frame.
+type Encoder interface {
+	Encode(frame []byte) ([]byte, error)
+}
+
+// Muxes frames into an FLV stream without ffmpeg.  Encoding is left to Encoder, this
+// only writes the FLV container around what it returns.
+type FLV struct {
+	W         io.Writer
+	Encoder   Encoder
+	Width     int
+	Height    int
+	FrameRate int
+	// Number of frames encoded at once, GOMAXPROCS when 0.  Tags are still written in
+	// frame order.  More than 1 needs an Encoder that is safe for concurrent use and
+	// doesn't depend on the frames before, so not one returning a sequence header
+	// first.  Frames are encoded one at a time when it's 1.
+	EncoderWorkers int
+}
+
+const (
+	flvTagVideo     = 9
+	flvHasVideo     = 0x01
+	flvHeaderSize   = 9
+	flvTagHeaderLen = 11
+)
+
+func (f *FLV) Run(ctx context.Context, frames io.Reader) error {
+	if f.FrameRate < 1 {
+		return ErrFrameRate
+	}
+	w := &FLVWriter{W: f.W}
+	if err := w.WriteHeader(); err != nil {
+		return err
+	}
+	if workers := f.workers(); workers > 1 {
+		return f.runWorkers(ctx, w, frames, workers)
+	}
+	buf := make([]byte, f.Width*f.Height*4)
+	r := &contextReader{ctx: ctx, r: frames}
+	for i := 0; ; i++ {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			// a partial frame at the end can't be encoded, so it ends the stream too
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("reading frame: %w", err)
+		}
+		body, err := f.Encoder.Encode(buf)
+		if err != nil {
+			return fmt.Errorf("encoding frame %d: %w", i, err)
+		}
+		if err := w.WriteVideoTag(uint32(i*1000/f.FrameRate), body); err != nil {
+			return err
+		}
+	}
+}
+
+// A frame and what it was encoded to
+type flvFrame struct {
+	idx   int
+	frame []byte
+	body  []byte
+	err   error
+}
+
+func (f *FLV) workers() int {
+	if f.EncoderWorkers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return f.EncoderWorkers
+}
+
+// Encodes frames on workers goroutines, holding the ones finished early until the
+// frames before them are written
+func (f *FLV) runWorkers(ctx context.Context, w *FLVWriter, frames io.Reader, workers int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// frame buffers are handed back once written, which bounds the frames in flight
+	free := make(chan []byte, workers*2)
+	for i := 0; i < cap(free); i++ {
+		free <- make([]byte, f.Width*f.Height*4)
+	}
+	jobs := make(chan flvFrame, workers)
+	// every frame in flight fits, so workers never wait on the writer
+	results := make(chan flvFrame, cap(free))
+	var readErr error
+	go func() {
+		defer close(jobs)
+		r := &contextReader{ctx: ctx, r: frames}
+		for i := 0; ; i++ {
+			var buf []byte
+			select {
+			case buf = <-free:
+			case <-ctx.Done():
+				return
+			}
+			if _, err := io.ReadFull(r, buf); err != nil {
+				// a partial frame at the end can't be encoded, so it ends the stream too
+				if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+					readErr = fmt.Errorf("reading frame: %w", err)
+				}
+				return
+			}
+			jobs <- flvFrame{idx: i, frame: buf}
+		}
+	}()
+	wg := sync.WaitGroup{}
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.body, job.err = f.Encoder.Encode(job.frame)
+				results <- job
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	var err error
+	pending := map[int]flvFrame{}
+	next := 0
+	// results are drained after an error so the workers can finish
+	for res := range results {
+		pending[res.idx] = res
+		for err == nil {
+			done, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if done.err != nil {
+				err = fmt.Errorf("encoding frame %d: %w", next, done.err)
+			} else {
+				err = w.WriteVideoTag(uint32(next*1000/f.FrameRate), done.body)
+			}
+			if err != nil {
+				cancel()
+				break
+			}
+			free <- done.frame
+			next++
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return readErr
+}
+
+// Writes the FLV container: the file header followed by tags, each trailed by its size
+type FLVWriter struct {
+	W io.Writer
+}
+
+// Writes the file header for a video only stream and the zero size of the tag before it
+func (fw *FLVWriter) WriteHeader() error {
+	header := []byte{'F', 'L', 'V', 1, flvHasVideo, 0, 0, 0, flvHeaderSize, 0, 0, 0, 0}
+	if _, err := fw.W.Write(header); err != nil {
+		return fmt.Errorf("writing flv header: %w", err)
+	}
+	return nil
+}
+
+// Writes a video tag with the timestamp in milliseconds
+func (fw *FLVWriter) WriteVideoTag(timestamp uint32, body []byte) error {
+	tag := make([]byte, flvTagHeaderLen, flvTagHeaderLen+len(body)+4)
+	tag[0] = flvTagVideo
+	putUint24(tag[1:], uint32(len(body)))
+	putUint24(tag[4:], timestamp)
+	tag[7] = byte(timestamp >> 24)
+	// bytes 8-10 are the stream id, always 0
+	tag = append(tag, body...)
+	tag = binary.BigEndian.AppendUint32(tag, uint32(flvTagHeaderLen+len(body)))
+	if _, err := fw.W.Write(tag); err != nil {
+		return fmt.Errorf("writing flv tag: %w", err)
+	}
+	return nil
+}
+
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v >> 16)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v)
+}
@@ -0,0 +1,127 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image/color"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Encodes a frame as a keyframe holding its first pixel
+type firstPixelEncoder struct{}
+
+func (firstPixelEncoder) Encode(frame []byte) ([]byte, error) {
+	return append([]byte{0x17}, frame[:4]...), nil
+}
+
+// Encodes like firstPixelEncoder but takes a different time over each frame, so
+// concurrent workers finish out of order
+type jitteryEncoder struct {
+	running atomic.Int32
+	most    atomic.Int32
+}
+
+func (je *jitteryEncoder) Encode(frame []byte) ([]byte, error) {
+	n := je.running.Add(1)
+	defer je.running.Add(-1)
+	for {
+		most := je.most.Load()
+		if n <= most || je.most.CompareAndSwap(most, n) {
+			break
+		}
+	}
+	time.Sleep(time.Duration(frame[0]+frame[1]*3+frame[2]*7) % 5 * time.Millisecond)
+	return firstPixelEncoder{}.Encode(frame)
+}
+
+func TestFLV(t *testing.T) {
+	var cols []color.RGBA
+	for i := 0; i < 16; i++ {
+		cols = append(cols, color.RGBA{uint8(i * 60), uint8(i * 25), uint8(255 - i*15), 255})
+	}
+	jittery := &jitteryEncoder{}
+	tests := map[string]*FLV{
+		"serial":  {Encoder: firstPixelEncoder{}, EncoderWorkers: 1},
+		"workers": {Encoder: jittery, EncoderWorkers: 4},
+	}
+	for name, sink := range tests {
+		t.Run(name, func(t *testing.T) {
+			testFLV(t, sink, cols)
+		})
+	}
+	if most := jittery.most.Load(); most < 2 {
+		t.Errorf("at most %d frames were encoded at once with 4 workers", most)
+	}
+}
+
+func testFLV(t *testing.T, sink *FLV, cols []color.RGBA) {
+	raw, err := io.ReadAll(newGradient(cols...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	frameSize := 4 * 2 * 4
+	buf := &bytes.Buffer{}
+	sink.W, sink.Width, sink.Height, sink.FrameRate = buf, 4, 2, 25
+	if err := Sink(sink).Run(context.Background(), newGradient(cols...)); err != nil {
+		t.Fatalf("running sink: %s", err)
+	}
+	b := buf.Bytes()
+	wantHeader := []byte{'F', 'L', 'V', 1, 1, 0, 0, 0, 9, 0, 0, 0, 0}
+	if !bytes.HasPrefix(b, wantHeader) {
+		t.Fatalf("header is % x, want % x", b[:min(len(b), len(wantHeader))], wantHeader)
+	}
+	b = b[len(wantHeader):]
+	tags := 0
+	for len(b) > 0 {
+		if len(b) < 11 {
+			t.Fatalf("tag %d: %d bytes left, too short for a tag header", tags, len(b))
+		}
+		size := int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+		timestamp := int(b[4])<<16 | int(b[5])<<8 | int(b[6]) | int(b[7])<<24
+		if b[0] != 9 {
+			t.Errorf("tag %d has type %d, want video", tags, b[0])
+		}
+		if want := tags * 1000 / 25; timestamp != want {
+			t.Errorf("tag %d has timestamp %d, want %d", tags, timestamp, want)
+		}
+		if len(b) < 11+size+4 {
+			t.Fatalf("tag %d is truncated", tags)
+		}
+		body := b[11 : 11+size]
+		if want := append([]byte{0x17}, raw[tags*frameSize:tags*frameSize+4]...); !bytes.Equal(body, want) {
+			t.Errorf("tag %d body is % x, want % x", tags, body, want)
+		}
+		if prev := binary.BigEndian.Uint32(b[11+size:]); int(prev) != 11+size {
+			t.Errorf("tag %d previous tag size is %d, want %d", tags, prev, 11+size)
+		}
+		b = b[11+size+4:]
+		tags++
+	}
+	if want := len(raw) / frameSize; tags != want {
+		t.Errorf("wrote %d tags, want one per frame (%d)", tags, want)
+	}
+}
+
+type failingEncoder struct{ after int32 }
+
+var errEncode = errors.New("encoder broke")
+
+func (fe *failingEncoder) Encode(frame []byte) ([]byte, error) {
+	if atomic.AddInt32(&fe.after, -1) < 0 {
+		return nil, errEncode
+	}
+	return firstPixelEncoder{}.Encode(frame)
+}
+
+func TestFLVWorkersError(t *testing.T) {
+	cols := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 255, 255}, {0, 0, 0, 255}}
+	sink := &FLV{W: io.Discard, Encoder: &failingEncoder{after: 2}, Width: 4, Height: 2, FrameRate: 25, EncoderWorkers: 3}
+	if err := sink.Run(context.Background(), newGradient(cols...)); !errors.Is(err, errEncode) {
+		t.Errorf("got %v, want the encoder's error", err)
+	}
+}
@@ -0,0 +1,38 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Consumes the raw RGBA frames produced by a frame maker
+type Sink interface {
+	// Blocks until the frames end, the sink fails, or the context is done
+	Run(ctx context.Context, frames io.Reader) error
+}
+
+// Writes the raw frames unchanged, ie. to stdout, a FIFO or a file
+type Raw struct {
+	W io.Writer
+}
+
+func (r *Raw) Run(ctx context.Context, frames io.Reader) error {
+	// stop between frames when the context is done
+	if _, err := io.Copy(r.W, &contextReader{ctx: ctx, r: frames}); err != nil {
+		return fmt.Errorf("writing frames: %w", err)
+	}
+	return nil
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(b []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(b)
+}
@@ -0,0 +1,54 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/broganross/color-run/internal/frame"
+)
+
+func newGradient(cols ...color.RGBA) *frame.LinearGradient {
+	ch := make(chan *color.RGBA, len(cols))
+	for i := range cols {
+		ch <- &cols[i]
+	}
+	close(ch)
+	lg := &frame.LinearGradient{
+		ColorChannel: ch,
+		Transition:   2,
+		Rect:         image.Rect(0, 0, 4, 2),
+	}
+	lg.Reset()
+	go lg.Run()
+	return lg
+}
+
+func TestRaw(t *testing.T) {
+	cols := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 255, 255}}
+	want, err := io.ReadAll(newGradient(cols...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	var sink Sink = &Raw{W: buf}
+	if err := sink.Run(context.Background(), newGradient(cols...)); err != nil {
+		t.Fatalf("running sink: %s", err)
+	}
+	if len(want) == 0 || !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("sink received %d bytes, want the %d frame bytes", buf.Len(), len(want))
+	}
+}
+
+func TestRawCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := (&Raw{W: io.Discard}).Run(ctx, bytes.NewReader(make([]byte, 64)))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,29 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Reads Frames frames before starting Sink, so it starts with a cushion rather than
+// waiting on the first frames to be made
+type Prebuffer struct {
+	Sink      Sink
+	Frames    int
+	FrameSize int
+}
+
+func (p *Prebuffer) Run(ctx context.Context, frames io.Reader) error {
+	buf := make([]byte, p.Frames*p.FrameSize)
+	n, err := io.ReadFull(&contextReader{ctx: ctx, r: frames}, buf)
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		// the frames ended early, pass on what there is
+	case err != nil:
+		return fmt.Errorf("buffering frames: %w", err)
+	}
+	return p.Sink.Run(ctx, io.MultiReader(bytes.NewReader(buf[:n]), frames))
+}
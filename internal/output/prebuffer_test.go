@@ -0,0 +1,65 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+// Counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (cr *countingReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += n
+	return n, err
+}
+
+// Records how much of the source had been read when it was started
+type startRecorder struct {
+	src     *countingReader
+	started int
+	got     []byte
+}
+
+func (sr *startRecorder) Run(ctx context.Context, frames io.Reader) error {
+	sr.started = sr.src.n
+	b, err := io.ReadAll(frames)
+	sr.got = b
+	return err
+}
+
+func TestPrebuffer(t *testing.T) {
+	frameSize := 16
+	frames := make([]byte, 10*frameSize)
+	for i := range frames {
+		frames[i] = byte(i)
+	}
+	src := &countingReader{r: bytes.NewReader(frames)}
+	rec := &startRecorder{src: src}
+	sink := &Prebuffer{Sink: rec, Frames: 3, FrameSize: frameSize}
+	if err := sink.Run(context.Background(), src); err != nil {
+		t.Fatalf("running sink: %s", err)
+	}
+	if rec.started < 3*frameSize {
+		t.Errorf("sink started after %d bytes, want at least %d", rec.started, 3*frameSize)
+	}
+	if !bytes.Equal(rec.got, frames) {
+		t.Errorf("sink received %d bytes, want all %d unchanged", len(rec.got), len(frames))
+	}
+
+	// fewer frames than the buffer still reach the sink
+	src = &countingReader{r: bytes.NewReader(frames[:frameSize])}
+	rec = &startRecorder{src: src}
+	sink = &Prebuffer{Sink: rec, Frames: 3, FrameSize: frameSize}
+	if err := sink.Run(context.Background(), src); err != nil {
+		t.Fatalf("running sink with a short input: %s", err)
+	}
+	if !bytes.Equal(rec.got, frames[:frameSize]) {
+		t.Errorf("sink received %d bytes, want %d", len(rec.got), frameSize)
+	}
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var ErrKilled = errors.New("killed after the shutdown timeout")
+
+// Runs the command until it exits.  Once the context is done it has timeout to exit
+// by itself, ie. after its input is closed, before it's killed.  A timeout of 0 waits
+// as long as it takes.
+func runProcess(ctx context.Context, cmd *exec.Cmd, timeout time.Duration) error {
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting process: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	select {
+	case err := <-exited:
+		return err
+	case <-ctx.Done():
+	}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case err := <-exited:
+		return err
+	case <-expired:
+		log.Warn().Dur("timeout", timeout).Msg("process didn't exit in time, killing it")
+		if err := cmd.Process.Kill(); err != nil {
+			return fmt.Errorf("killing process: %w", err)
+		}
+		<-exited
+		return ErrKilled
+	}
+}
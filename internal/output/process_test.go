@@ -0,0 +1,41 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunProcessKillsAfterTimeout(t *testing.T) {
+	// ignores its input closing, like a wedged ffmpeg
+	cmd := exec.Command("sleep", "30")
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- runProcess(ctx, cmd, 50*time.Millisecond)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancelled := time.Now()
+	cancel()
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrKilled) {
+			t.Errorf("got %v, want %v", err, ErrKilled)
+		}
+		if waited := time.Since(cancelled); waited < 50*time.Millisecond {
+			t.Errorf("killed after %s, before the timeout", waited)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("process wasn't killed")
+	}
+}
+
+func TestRunProcessExitsByItself(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := runProcess(ctx, exec.Command("true"), time.Second); err != nil {
+		t.Errorf("got %v, want the process's own exit", err)
+	}
+}
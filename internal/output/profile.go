@@ -0,0 +1,43 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/broganross/color-run/internal/frame"
+)
+
+var ErrColorProfile = errors.New("unknown color profile")
+
+// Ties the space colors are interpolated in to how the encoded stream is tagged, so
+// the two can't disagree
+type ColorProfile struct {
+	Mix frame.MixSpace
+	// ffmpeg output options describing the stream's color
+	FFmpegArgs map[string]string
+	// Matrix ffmpeg converts the RGB frames to YUV with, which has to match the
+	// colorspace tag or players shift the hues
+	Matrix string
+}
+
+// Frames are always sRGB encoded whatever space they're mixed in, so every profile
+// tags the stream as such, and converts it to YUV with the BT.709 matrix the tag names
+var srgbTags = map[string]string{
+	"color_primaries": "bt709",
+	"color_trc":       "iec61966-2-1",
+	"colorspace":      "bt709",
+}
+
+var ColorProfiles = map[string]ColorProfile{
+	"srgb":      {Mix: frame.MixSRGB, FFmpegArgs: srgbTags, Matrix: "bt709"},
+	"linear":    {Mix: frame.MixLinear, FFmpegArgs: srgbTags, Matrix: "bt709"},
+	"oklab-mix": {Mix: frame.MixOKLab, FFmpegArgs: srgbTags, Matrix: "bt709"},
+}
+
+func ParseColorProfile(name string) (ColorProfile, error) {
+	p, ok := ColorProfiles[name]
+	if !ok {
+		return ColorProfile{}, fmt.Errorf("%w: %q", ErrColorProfile, name)
+	}
+	return p, nil
+}
@@ -0,0 +1,46 @@
+package output
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/broganross/color-run/internal/frame"
+)
+
+func TestColorProfile(t *testing.T) {
+	tests := map[string]frame.MixSpace{
+		"srgb":      frame.MixSRGB,
+		"linear":    frame.MixLinear,
+		"oklab-mix": frame.MixOKLab,
+	}
+	for name, mix := range tests {
+		p, err := ParseColorProfile(name)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if p.Mix != mix {
+			t.Errorf("%s: mixes in %s, want %s", name, p.Mix, mix)
+		}
+		args := (&FFmpeg{FrameRate: 30, ColorArgs: p.FFmpegArgs, ColorMatrix: p.Matrix}).outputArgs()
+		want := map[string]string{"color_primaries": "bt709", "color_trc": "iec61966-2-1", "colorspace": "bt709"}
+		for k, v := range want {
+			if args[k] != v {
+				t.Errorf("%s: ffmpeg %s is %v, want %s", name, k, args[k], v)
+			}
+		}
+		// converted with the matrix the stream is tagged with
+		if args["vf"] != "scale=out_color_matrix=bt709" {
+			t.Errorf("%s: ffmpeg vf is %v, want the bt709 matrix", name, args["vf"])
+		}
+		scaled := (&FFmpeg{Width: 960, Height: 540, ScaleWidth: 1920, ScaleHeight: 1080, ColorMatrix: p.Matrix}).outputArgs()
+		if scaled["vf"] != "scale=1920:1080:out_color_matrix=bt709" {
+			t.Errorf("%s: scaled ffmpeg vf is %v, want the bt709 matrix", name, scaled["vf"])
+		}
+		if args["c:v"] != "libx264" {
+			t.Errorf("%s: the profile replaced the codec", name)
+		}
+	}
+	if _, err := ParseColorProfile("rec2020"); !errors.Is(err, ErrColorProfile) {
+		t.Errorf("got %v, want %v", err, ErrColorProfile)
+	}
+}
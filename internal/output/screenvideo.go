@@ -0,0 +1,77 @@
+package output
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrScreenVideoSize = errors.New("frame too big for screen video")
+
+const (
+	// keyframe in the top 4 bits, codec 3, screen video, in the bottom
+	screenVideoKeyframe = 0x13
+	// blocks are stored in multiples of 16 pixels, up to 256
+	screenVideoBlock = 64
+	// widths and heights are stored in 12 bits
+	screenVideoMaxSize = 1<<12 - 1
+)
+
+// Encodes frames with FLV's Screen Video codec, zlib compressed blocks of pixels, so
+// the FLV sink can write a stream ffmpeg and most players read without ffmpeg encoding
+// it.  Every frame is a keyframe, so frames can be encoded in any order and on several
+// goroutines at once.  Alpha is dropped.  The files are much bigger than H.264.
+type ScreenVideo struct {
+	Width  int
+	Height int
+	// zlib writers, which are costly to make for every block
+	writers sync.Pool
+}
+
+func (sv *ScreenVideo) Encode(frame []byte) ([]byte, error) {
+	if sv.Width < 1 || sv.Height < 1 || sv.Width > screenVideoMaxSize || sv.Height > screenVideoMaxSize {
+		return nil, fmt.Errorf("%w: %dx%d", ErrScreenVideoSize, sv.Width, sv.Height)
+	}
+	if len(frame) < sv.Width*sv.Height*4 {
+		return nil, fmt.Errorf("%w: %d bytes for a %dx%d frame", ErrScreenVideoSize, len(frame), sv.Width, sv.Height)
+	}
+	body := []byte{screenVideoKeyframe}
+	body = binary.BigEndian.AppendUint16(body, uint16(screenVideoBlock/16-1)<<12|uint16(sv.Width))
+	body = binary.BigEndian.AppendUint16(body, uint16(screenVideoBlock/16-1)<<12|uint16(sv.Height))
+	zw, _ := sv.writers.Get().(*zlib.Writer)
+	if zw == nil {
+		zw = zlib.NewWriter(nil)
+	}
+	defer sv.writers.Put(zw)
+	compressed := bytes.Buffer{}
+	pixels := make([]byte, 0, screenVideoBlock*screenVideoBlock*3)
+	// blocks run left to right from the bottom row of blocks up, and the rows in each
+	// from the bottom up too
+	for top := (sv.Height - 1) / screenVideoBlock * screenVideoBlock; top >= 0; top -= screenVideoBlock {
+		bottom := min(top+screenVideoBlock, sv.Height)
+		for left := 0; left < sv.Width; left += screenVideoBlock {
+			right := min(left+screenVideoBlock, sv.Width)
+			pixels = pixels[:0]
+			for y := bottom - 1; y >= top; y-- {
+				for x := left; x < right; x++ {
+					i := (y*sv.Width + x) * 4
+					pixels = append(pixels, frame[i+2], frame[i+1], frame[i])
+				}
+			}
+			compressed.Reset()
+			zw.Reset(&compressed)
+			if _, err := zw.Write(pixels); err != nil {
+				return nil, fmt.Errorf("compressing block: %w", err)
+			}
+			if err := zw.Close(); err != nil {
+				return nil, fmt.Errorf("compressing block: %w", err)
+			}
+			body = binary.BigEndian.AppendUint16(body, uint16(compressed.Len()))
+			body = append(body, compressed.Bytes()...)
+		}
+	}
+	return body, nil
+}
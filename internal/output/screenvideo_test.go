@@ -0,0 +1,73 @@
+package output
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// Decodes a screen video keyframe back to RGBA with opaque alpha
+func decodeScreenVideo(t *testing.T, body []byte) (int, int, []byte) {
+	t.Helper()
+	if body[0] != screenVideoKeyframe {
+		t.Fatalf("frame starts with %#x, want a screen video keyframe", body[0])
+	}
+	wField, hField := binary.BigEndian.Uint16(body[1:]), binary.BigEndian.Uint16(body[3:])
+	bw, bh := int(wField>>12+1)*16, int(hField>>12+1)*16
+	width, height := int(wField&0xfff), int(hField&0xfff)
+	frame := make([]byte, width*height*4)
+	rest := body[5:]
+	for top := (height - 1) / bh * bh; top >= 0; top -= bh {
+		for left := 0; left < width; left += bw {
+			size := int(binary.BigEndian.Uint16(rest))
+			zr, err := zlib.NewReader(bytes.NewReader(rest[2 : 2+size]))
+			if err != nil {
+				t.Fatalf("block at %d,%d: %s", left, top, err)
+			}
+			pixels, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("block at %d,%d: %s", left, top, err)
+			}
+			rest = rest[2+size:]
+			for y := min(top+bh, height) - 1; y >= top; y-- {
+				for x := left; x < min(left+bw, width); x++ {
+					i := (y*width + x) * 4
+					frame[i], frame[i+1], frame[i+2], frame[i+3] = pixels[2], pixels[1], pixels[0], 255
+					pixels = pixels[3:]
+				}
+			}
+		}
+	}
+	if len(rest) != 0 {
+		t.Errorf("%d bytes left after the blocks", len(rest))
+	}
+	return width, height, frame
+}
+
+func TestScreenVideo(t *testing.T) {
+	// not a multiple of the block size either way, so the edge blocks are partial
+	width, height := 150, 70
+	frame := make([]byte, width*height*4)
+	for i := 0; i < len(frame); i += 4 {
+		p := i / 4
+		frame[i], frame[i+1], frame[i+2], frame[i+3] = byte(p%width), byte(p/width), byte(p*7), 255
+	}
+	sv := &ScreenVideo{Width: width, Height: height}
+	body, err := sv.Encode(frame)
+	if err != nil {
+		t.Fatalf("encoding: %s", err)
+	}
+	w, h, got := decodeScreenVideo(t, body)
+	if w != width || h != height {
+		t.Errorf("decoded a %dx%d frame, want %dx%d", w, h, width, height)
+	}
+	if !bytes.Equal(got, frame) {
+		t.Error("decoded frame differs from the one encoded")
+	}
+	if _, err := (&ScreenVideo{Width: 5000, Height: 10}).Encode(make([]byte, 5000*10*4)); !errors.Is(err, ErrScreenVideoSize) {
+		t.Errorf("got %v encoding a frame too wide, want %v", err, ErrScreenVideoSize)
+	}
+}
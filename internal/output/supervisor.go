@@ -0,0 +1,119 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Reruns a sink which fails, ie. ffmpeg crashing or losing its connection, so a long
+// running stream carries on.  Every attempt gets a new sink from New, so the ingest
+// server can be looked up again.  The frames carry on from where the failed sink left
+// them, skipping to the start of the next whole frame.
+type Supervisor struct {
+	New func(ctx context.Context) (Sink, error)
+	// Size of a frame in bytes
+	FrameSize int
+	// Restarts in a row before a failure ends the run, 0 never restarts
+	MaxRestarts int
+	// Wait before the first restart, doubling with each one after it up to MaxDelay.
+	// Default to a second and a minute.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// A sink which ran at least this long before failing was working, so the
+	// restarts start again from the first.  Defaults to a minute.
+	Healthy time.Duration
+	// Called every time the sink is restarted, ie. to count them
+	OnRestart func()
+	// Defaults to the global logger
+	Logger *zerolog.Logger
+}
+
+func (s *Supervisor) Run(ctx context.Context, frames io.Reader) error {
+	logger := s.Logger
+	if logger == nil {
+		logger = &log.Logger
+	}
+	healthy := s.Healthy
+	if healthy <= 0 {
+		healthy = time.Minute
+	}
+	fa := &frameAligner{r: frames}
+	restarts := 0
+	for {
+		start := time.Now()
+		sink, err := s.New(ctx)
+		if err != nil {
+			err = fmt.Errorf("making output: %w", err)
+		} else {
+			err = sink.Run(ctx, fa)
+		}
+		if time.Since(start) >= healthy {
+			restarts = 0
+		}
+		delay, ok := s.restart(ctx, err, restarts)
+		if !ok {
+			return err
+		}
+		restarts++
+		if s.OnRestart != nil {
+			s.OnRestart()
+		}
+		logger.Warn().Err(err).Int("restart", restarts).Dur("delay", delay).Msg("output failed, restarting it")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		if err := fa.align(s.FrameSize); err != nil {
+			return fmt.Errorf("skipping to the next frame: %w", err)
+		}
+	}
+}
+
+// Decides whether a sink which returned err should be restarted, after the restarts
+// in a row so far, and how long to wait first.  Sinks which finished or were
+// cancelled aren't.
+func (s *Supervisor) restart(ctx context.Context, err error, restarts int) (time.Duration, bool) {
+	if err == nil || ctx.Err() != nil || restarts >= s.MaxRestarts {
+		return 0, false
+	}
+	base, most := s.BaseDelay, s.MaxDelay
+	if base <= 0 {
+		base = time.Second
+	}
+	if most <= 0 {
+		most = time.Minute
+	}
+	delay := base
+	for i := 0; i < restarts && delay < most; i++ {
+		delay *= 2
+	}
+	return min(delay, most), true
+}
+
+// Counts what's been read so the next reader can start on a frame boundary
+type frameAligner struct {
+	r    io.Reader
+	read int64
+}
+
+func (fa *frameAligner) Read(b []byte) (int, error) {
+	n, err := fa.r.Read(b)
+	fa.read += int64(n)
+	return n, err
+}
+
+// Discards the rest of a partly read frame
+func (fa *frameAligner) align(frameSize int) error {
+	if frameSize <= 0 {
+		return nil
+	}
+	skip := (int64(frameSize) - fa.read%int64(frameSize)) % int64(frameSize)
+	_, err := io.CopyN(io.Discard, fa, skip)
+	return err
+}
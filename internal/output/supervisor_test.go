@@ -0,0 +1,102 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSupervisorRestart(t *testing.T) {
+	crashed := errors.New("ffmpeg crashed")
+	s := &Supervisor{MaxRestarts: 4, BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	tests := []struct {
+		err      error
+		restarts int
+		delay    time.Duration
+		ok       bool
+	}{
+		{nil, 0, 0, false},
+		{crashed, 0, time.Second, true},
+		{crashed, 1, 2 * time.Second, true},
+		{crashed, 2, 4 * time.Second, true},
+		// capped
+		{crashed, 3, 5 * time.Second, true},
+		{crashed, 4, 0, false},
+	}
+	for _, test := range tests {
+		delay, ok := s.restart(context.Background(), test.err, test.restarts)
+		if delay != test.delay || ok != test.ok {
+			t.Errorf("after %d restarts with %v got %s, %t, want %s, %t", test.restarts, test.err, delay, ok, test.delay, test.ok)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := s.restart(ctx, crashed, 0); ok {
+		t.Error("restarting after the context was cancelled")
+	}
+	if _, ok := (&Supervisor{}).restart(context.Background(), crashed, 0); ok {
+		t.Error("restarting with no restarts allowed")
+	}
+}
+
+// Reads n bytes and fails, or reads everything when n is negative
+type partialSink struct {
+	n   int
+	got []byte
+}
+
+func (ps *partialSink) Run(ctx context.Context, frames io.Reader) error {
+	if ps.n < 0 {
+		var err error
+		ps.got, err = io.ReadAll(frames)
+		return err
+	}
+	ps.got = make([]byte, ps.n)
+	if _, err := io.ReadFull(frames, ps.got); err != nil {
+		return err
+	}
+	return errors.New("ffmpeg crashed")
+}
+
+func TestSupervisor(t *testing.T) {
+	frames := make([]byte, 6*4)
+	for i := range frames {
+		frames[i] = byte(i / 4)
+	}
+	// each crash leaves a frame part read
+	sinks := []*partialSink{{n: 6}, {n: 1}, {n: -1}}
+	made := 0
+	restarted := 0
+	s := &Supervisor{
+		New: func(ctx context.Context) (Sink, error) {
+			made++
+			return sinks[made-1], nil
+		},
+		FrameSize:   4,
+		MaxRestarts: 2,
+		BaseDelay:   time.Millisecond,
+		OnRestart:   func() { restarted++ },
+	}
+	if err := s.Run(context.Background(), bytes.NewReader(frames)); err != nil {
+		t.Fatalf("running: %s", err)
+	}
+	if made != 3 || restarted != 2 {
+		t.Fatalf("made %d sinks and restarted %d times, want 3 and 2", made, restarted)
+	}
+	if want := frames[8:9]; !bytes.Equal(sinks[1].got, want) {
+		t.Errorf("first restart started at % x, want the start of frame 2", sinks[1].got)
+	}
+	if want := frames[12:]; !bytes.Equal(sinks[2].got, want) {
+		t.Errorf("second restart got % x, want the frames from 3 on % x", sinks[2].got, want)
+	}
+
+	// one crash too many ends the run with it
+	made = 0
+	sinks = []*partialSink{{n: 4}, {n: 4}, {n: 4}}
+	if err := s.Run(context.Background(), bytes.NewReader(frames)); err == nil {
+		t.Error("expected the third crash to end the run")
+	}
+}
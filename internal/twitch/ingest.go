@@ -3,46 +3,255 @@ package twitch
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
-func IngestURL(ctx context.Context, client *http.Client, streamKey string) (string, error) {
+var ErrDecode = errors.New("decoding ingest response")
+
+const ingestsEndpoint = "https://ingest.twitch.tv/ingests"
+
+// Returns the default ingest URL for the stream key.  A response which can't be decoded is
+// retried once as it's usually a transient fault between here and twitch.  Options narrow
+// down or change how the server is chosen.
+func IngestURL(ctx context.Context, client *http.Client, streamKey string, opts ...IngestOption) (string, error) {
+	return ingestURL(ctx, client, ingestsEndpoint, streamKey, 1, opts...)
+}
+
+func ingestURL(ctx context.Context, client *http.Client, endpoint string, streamKey string, retries int, opts ...IngestOption) (string, error) {
+	urls, err := ingestURLs(ctx, client, endpoint, streamKey, retries, opts...)
+	if err != nil {
+		return "", err
+	}
+	return urls[0], nil
+}
+
+// Returns the URL IngestURL would followed by the other servers allowed by the options,
+// most available first, to fall back to when streaming to the first fails
+func IngestURLs(ctx context.Context, client *http.Client, streamKey string, opts ...IngestOption) ([]string, error) {
+	return ingestURLs(ctx, client, ingestsEndpoint, streamKey, 1, opts...)
+}
+
+func ingestURLs(ctx context.Context, client *http.Client, endpoint string, streamKey string, retries int, opts ...IngestOption) ([]string, error) {
+	servers, err := ingestServers(ctx, client, endpoint, retries)
+	if err != nil {
+		return nil, err
+	}
+	o := ingestOptions{rtt: dialRTT}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ordered, err := o.order(ctx, servers)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, len(ordered))
+	for i, in := range ordered {
+		urls[i] = in.URL(streamKey)
+	}
+	return urls, nil
+}
+
+// Returns every ingest server in the order twitch lists them, for choosing one
+// yourself.  A response which can't be decoded is retried once like IngestURL's.
+func IngestServers(ctx context.Context, client *http.Client) ([]IngestServer, error) {
+	return ingestServers(ctx, client, ingestsEndpoint, 1)
+}
+
+func ingestServers(ctx context.Context, client *http.Client, endpoint string, retries int) ([]IngestServer, error) {
+	for attempt := 0; ; attempt++ {
+		r, err := getIngests(ctx, client, endpoint)
+		if err == nil {
+			return r.Ingests, nil
+		}
+		if !errors.Is(err, ErrDecode) || attempt >= retries {
+			return nil, err
+		}
+		log.Warn().Err(err).Msg("retrying ingest request")
+	}
+}
+
+func getIngests(ctx context.Context, client *http.Client, endpoint string) (ingestsResponse, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://ingest.twitch.tv/ingests", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
-		return "", fmt.Errorf("making http request: %w", err)
+		return ingestsResponse{}, fmt.Errorf("making http request: %w", err)
 	}
 	ingestResp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("getting ingests")
+		return ingestsResponse{}, fmt.Errorf("getting ingests: %w", err)
 	} else if ingestResp.StatusCode < http.StatusOK || ingestResp.StatusCode > http.StatusIMUsed {
 		defer ingestResp.Body.Close()
 		b, err := io.ReadAll(ingestResp.Body)
 		if err != nil {
-			return "", fmt.Errorf("reading ingest response body: %w", err)
+			return ingestsResponse{}, fmt.Errorf("reading ingest response body: %w", err)
 		}
 		err = fmt.Errorf("getting ingest (%s): %s", http.StatusText(ingestResp.StatusCode), string(b))
-		return "", err
+		return ingestsResponse{}, err
 	}
 	defer ingestResp.Body.Close()
 	r := ingestsResponse{}
 	if err := json.NewDecoder(ingestResp.Body).Decode(&r); err != nil {
-		return "", fmt.Errorf("decoding ingest response: %w", err)
+		return ingestsResponse{}, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+	return r, nil
+}
+
+var ErrNoIngest = errors.New("no ingest server found")
+
+// How long LowestLatency waits for each server to answer
+const rttTimeout = 2 * time.Second
+
+// Changes how IngestURL chooses a server
+type IngestOption func(*ingestOptions)
+
+type ingestOptions struct {
+	region        string
+	lowestLatency bool
+	// time to connect to the host:port
+	rtt func(ctx context.Context, addr string) (time.Duration, error)
+}
+
+// Only chooses from the servers with the region in their name, ie. "EU" or "Europe".
+// Case is ignored.
+func PreferRegion(region string) IngestOption {
+	return func(o *ingestOptions) {
+		o.region = region
 	}
-	var ingestURL string
-	for _, i := range r.Ingests {
-		if i.Default {
-			ingestURL = i.URLTemplate
+}
+
+// Chooses the server which takes the least time to connect to, rather than the default
+func LowestLatency() IngestOption {
+	return func(o *ingestOptions) {
+		o.lowestLatency = true
+	}
+}
+
+// Returns the servers in the region, the chosen one first and the rest by
+// availability, then priority
+func (o *ingestOptions) order(ctx context.Context, ingests []IngestServer) ([]IngestServer, error) {
+	var candidates []IngestServer
+	for _, in := range ingests {
+		if strings.Contains(strings.ToLower(in.Name), strings.ToLower(o.region)) {
+			candidates = append(candidates, in)
+		}
+	}
+	if len(candidates) == 0 {
+		if o.region != "" {
+			return nil, fmt.Errorf("%w in region %q", ErrNoIngest, o.region)
+		}
+		return nil, ErrNoIngest
+	}
+	chosen, err := o.choose(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	ordered := []IngestServer{chosen}
+	for _, in := range candidates {
+		if in != chosen {
+			ordered = append(ordered, in)
+		}
+	}
+	rest := ordered[1:]
+	sort.SliceStable(rest, func(i, j int) bool {
+		if rest[i].Availability != rest[j].Availability {
+			return rest[i].Availability > rest[j].Availability
+		}
+		return rest[i].Priority < rest[j].Priority
+	})
+	return ordered, nil
+}
+
+// Picks a server from the candidates.  Without LowestLatency that's the default, or
+// the first listed when the region leaves out the default.  When no server can be
+// reached that's chosen too.
+func (o *ingestOptions) choose(ctx context.Context, candidates []IngestServer) (IngestServer, error) {
+	if o.lowestLatency {
+		if in, ok := o.fastest(ctx, candidates); ok {
+			return in, nil
+		}
+		log.Warn().Msg("no ingest server answered, using the default")
+	}
+	for _, in := range candidates {
+		if in.Default {
+			return in, nil
 		}
 	}
-	if ingestURL == "" {
-		return "", fmt.Errorf("no default ingest server found")
+	if o.region == "" {
+		return IngestServer{}, fmt.Errorf("%w: none is the default", ErrNoIngest)
+	}
+	return candidates[0], nil
+}
+
+// Connects to every server at once and returns the quickest to answer
+func (o *ingestOptions) fastest(ctx context.Context, ingests []IngestServer) (IngestServer, bool) {
+	rtts := make([]time.Duration, len(ingests))
+	wg := sync.WaitGroup{}
+	for i, in := range ingests {
+		rtts[i] = -1
+		addr, err := ingestAddr(in.URLTemplate)
+		if err != nil {
+			log.Warn().Err(err).Str("ingest", in.Name).Msg("parsing ingest url")
+			continue
+		}
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, rttTimeout)
+			defer cancel()
+			rtt, err := o.rtt(ctx, addr)
+			if err != nil {
+				log.Debug().Err(err).Str("address", addr).Msg("measuring ingest latency")
+				return
+			}
+			rtts[i] = rtt
+		}(i, addr)
+	}
+	wg.Wait()
+	best := -1
+	for i, rtt := range rtts {
+		if rtt >= 0 && (best < 0 || rtt < rtts[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return IngestServer{}, false
+	}
+	log.Debug().Str("ingest", ingests[best].Name).Dur("rtt", rtts[best]).Msg("chose the quickest ingest server")
+	return ingests[best], true
+}
+
+// Returns the host:port of an ingest URL template, RTMP's port when it has none
+func ingestAddr(template string) (string, error) {
+	u, err := url.Parse(template)
+	if err != nil {
+		return "", err
+	}
+	if u.Port() != "" {
+		return u.Host, nil
+	}
+	return net.JoinHostPort(u.Hostname(), "1935"), nil
+}
+
+// Time to open a TCP connection to addr
+func dialRTT(ctx context.Context, addr string) (time.Duration, error) {
+	start := time.Now()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, err
 	}
-	ingestURL = strings.Replace(ingestURL, "{stream_key}", streamKey, -1)
-	return ingestURL, nil
+	rtt := time.Since(start)
+	conn.Close()
+	return rtt, nil
 }
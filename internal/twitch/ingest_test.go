@@ -0,0 +1,198 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const ingestsBody = `{"ingests":[{"_id":1,"default":false,"url_template":"rtmp://other/app/{stream_key}"},{"_id":2,"default":true,"url_template":"rtmp://default/app/{stream_key}"}]}`
+
+// Serves truncated JSON for the first bad requests, then the full response
+func newIngestServer(t *testing.T, bad int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= bad {
+			w.Write([]byte(ingestsBody[:40]))
+			return
+		}
+		w.Write([]byte(ingestsBody))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestIngestURLRetriesDecode(t *testing.T) {
+	srv, requests := newIngestServer(t, 1)
+	got, err := ingestURL(context.Background(), srv.Client(), srv.URL, "key", 1)
+	if err != nil {
+		t.Fatalf("getting ingest url: %s", err)
+	}
+	if want := "rtmp://default/app/key"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
+
+func TestIngestURLRetriesOnce(t *testing.T) {
+	srv, requests := newIngestServer(t, 2)
+	if _, err := ingestURL(context.Background(), srv.Client(), srv.URL, "key", 1); !errors.Is(err, ErrDecode) {
+		t.Errorf("got %v, want %v", err, ErrDecode)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
+
+func TestIngestURLStatusNotRetried(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	if _, err := ingestURL(context.Background(), srv.Client(), srv.URL, "key", 1); err == nil {
+		t.Error("expected an error")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("made %d requests, want 1", n)
+	}
+}
+
+const regionsBody = `{"ingests":[
+{"_id":1,"name":"US West: San Francisco, CA","default":true,"url_template":"rtmp://sfo.contribute.live-video.net/app/{stream_key}"},
+{"_id":2,"name":"EU: Frankfurt, DE","default":false,"url_template":"rtmp://fra.contribute.live-video.net/app/{stream_key}"},
+{"_id":3,"name":"EU: London, UK","default":false,"url_template":"rtmp://lhr.contribute.live-video.net:1936/app/{stream_key}"}]}`
+
+func newRegionsServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(regionsBody))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestIngestURLRegion(t *testing.T) {
+	srv := newRegionsServer(t)
+	tests := []struct {
+		opts []IngestOption
+		want string
+	}{
+		{nil, "rtmp://sfo.contribute.live-video.net/app/key"},
+		{[]IngestOption{PreferRegion("EU")}, "rtmp://fra.contribute.live-video.net/app/key"},
+		{[]IngestOption{PreferRegion("london")}, "rtmp://lhr.contribute.live-video.net:1936/app/key"},
+		{[]IngestOption{PreferRegion("US West")}, "rtmp://sfo.contribute.live-video.net/app/key"},
+	}
+	for _, test := range tests {
+		got, err := ingestURL(context.Background(), srv.Client(), srv.URL, "key", 0, test.opts...)
+		if err != nil {
+			t.Errorf("getting ingest url: %s", err)
+		} else if got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+	if _, err := ingestURL(context.Background(), srv.Client(), srv.URL, "key", 0, PreferRegion("Asia")); !errors.Is(err, ErrNoIngest) {
+		t.Errorf("got %v for a region without servers, want %v", err, ErrNoIngest)
+	}
+}
+
+func TestIngestURLLowestLatency(t *testing.T) {
+	srv := newRegionsServer(t)
+	rtts := map[string]time.Duration{
+		"sfo.contribute.live-video.net:1935": 150 * time.Millisecond,
+		"fra.contribute.live-video.net:1935": 30 * time.Millisecond,
+		"lhr.contribute.live-video.net:1936": 10 * time.Millisecond,
+	}
+	var unreachable sync.Map
+	fakeRTT := func(o *ingestOptions) {
+		o.rtt = func(ctx context.Context, addr string) (time.Duration, error) {
+			if _, ok := unreachable.Load(addr); ok {
+				return 0, errors.New("connection refused")
+			}
+			return rtts[addr], nil
+		}
+	}
+	got, err := ingestURL(context.Background(), srv.Client(), srv.URL, "key", 0, LowestLatency(), fakeRTT)
+	if err != nil {
+		t.Fatalf("getting ingest url: %s", err)
+	}
+	if want := "rtmp://lhr.contribute.live-video.net:1936/app/key"; got != want {
+		t.Errorf("got %q, want the quickest %q", got, want)
+	}
+	// servers which can't be reached aren't chosen, and with none left it's the default
+	unreachable.Store("lhr.contribute.live-video.net:1936", true)
+	got, _ = ingestURL(context.Background(), srv.Client(), srv.URL, "key", 0, LowestLatency(), fakeRTT)
+	if want := "rtmp://fra.contribute.live-video.net/app/key"; got != want {
+		t.Errorf("got %q, want %q once the quickest is unreachable", got, want)
+	}
+	for addr := range rtts {
+		unreachable.Store(addr, true)
+	}
+	got, _ = ingestURL(context.Background(), srv.Client(), srv.URL, "key", 0, LowestLatency(), fakeRTT)
+	if want := "rtmp://sfo.contribute.live-video.net/app/key"; got != want {
+		t.Errorf("got %q, want the default %q when none answer", got, want)
+	}
+}
+
+func TestIngestServers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"_links":{},"ingests":[
+{"_id":24,"availability":1.0,"default":false,"name":"EU: Amsterdam, NL","url_template":"rtmp://ams03.contribute.live-video.net/app/{stream_key}","url_template_secure":"rtmps://ams03.contribute.live-video.net/app/{stream_key}","priority":52},
+{"_id":1,"availability":0.5,"default":true,"name":"US West: San Francisco, CA","url_template":"rtmp://sfo.contribute.live-video.net/app/{stream_key}","priority":0}]}`))
+	}))
+	defer srv.Close()
+	got, err := ingestServers(context.Background(), srv.Client(), srv.URL, 0)
+	if err != nil {
+		t.Fatalf("getting ingest servers: %s", err)
+	}
+	want := []IngestServer{
+		{ID: 24, Availability: 1, Name: "EU: Amsterdam, NL", URLTemplate: "rtmp://ams03.contribute.live-video.net/app/{stream_key}", Priority: 52},
+		{ID: 1, Availability: 0.5, Default: true, Name: "US West: San Francisco, CA", URLTemplate: "rtmp://sfo.contribute.live-video.net/app/{stream_key}"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got, want := got[0].URL("key"), "rtmp://ams03.contribute.live-video.net/app/key"; got != want {
+		t.Errorf("got url %q, want %q", got, want)
+	}
+}
+
+func TestIngestURLsOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ingests":[
+{"_id":1,"availability":0.5,"name":"EU: Paris, FR","url_template":"rtmp://cdg/app/{stream_key}","priority":1},
+{"_id":2,"availability":1.0,"name":"EU: Madrid, ES","url_template":"rtmp://mad/app/{stream_key}","priority":2},
+{"_id":3,"availability":0.2,"default":true,"name":"US East: New York, NY","url_template":"rtmp://jfk/app/{stream_key}","priority":5},
+{"_id":4,"availability":1.0,"name":"EU: Berlin, DE","url_template":"rtmp://ber/app/{stream_key}","priority":1},
+{"_id":5,"availability":0,"name":"EU: Oslo, NO","url_template":"rtmp://osl/app/{stream_key}","priority":0}]}`))
+	}))
+	defer srv.Close()
+	got, err := ingestURLs(context.Background(), srv.Client(), srv.URL, "key", 0)
+	if err != nil {
+		t.Fatalf("getting ingest urls: %s", err)
+	}
+	// the default first however available it is, then the most available, ties broken by priority
+	want := []string{"rtmp://jfk/app/key", "rtmp://ber/app/key", "rtmp://mad/app/key", "rtmp://cdg/app/key", "rtmp://osl/app/key"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	got, err = ingestURLs(context.Background(), srv.Client(), srv.URL, "key", 0, PreferRegion("EU"))
+	if err != nil {
+		t.Fatalf("getting ingest urls: %s", err)
+	}
+	// the region has no default so the first listed is chosen
+	want = []string{"rtmp://cdg/app/key", "rtmp://ber/app/key", "rtmp://mad/app/key", "rtmp://osl/app/key"}
+	if !slices.Equal(got, want) {
+		t.Errorf("in the EU got %q, want %q", got, want)
+	}
+}
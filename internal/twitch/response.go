@@ -1,12 +1,23 @@
 package twitch
 
+import "strings"
+
 type ingestsResponse struct {
-	Ingests []struct {
-		ID           int     `json:"_id"`
-		Availability float64 `json:"availability"`
-		Default      bool    `json:"default"`
-		Name         string  `json:"name"`
-		URLTemplate  string  `json:"url_template"`
-		Priority     int     `json:"priority"`
-	} `json:"ingests"`
+	Ingests []IngestServer `json:"ingests"`
+}
+
+// One of twitch's ingest servers
+type IngestServer struct {
+	ID           int     `json:"_id"`
+	Availability float64 `json:"availability"`
+	Default      bool    `json:"default"`
+	Name         string  `json:"name"`
+	// RTMP URL with a {stream_key} placeholder
+	URLTemplate string `json:"url_template"`
+	Priority    int    `json:"priority"`
+}
+
+// The URL to stream to with the stream key
+func (is IngestServer) URL(streamKey string) string {
+	return strings.ReplaceAll(is.URLTemplate, "{stream_key}", streamKey)
 }
@@ -0,0 +1,33 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var ErrStreamKey = errors.New("invalid stream key")
+
+// live_, the channel's numeric ID, then a random token
+var streamKeyPattern = regexp.MustCompile(`^live_[0-9]+_[A-Za-z0-9]{20,}$`)
+
+// Checks the stream key looks like one twitch hands out, so a placeholder or a key
+// copied with part missing is caught before ffmpeg fails with an RTMP error.  Twitch
+// could change the format, so callers may want to only warn about a key this rejects.
+func ValidateStreamKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty", ErrStreamKey)
+	}
+	if !streamKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: %q doesn't look like live_<channel id>_<token>", ErrStreamKey, redactKey(key))
+	}
+	return nil
+}
+
+// Keeps enough of the key to recognise it without logging the secret part
+func redactKey(key string) string {
+	if len(key) <= 8 {
+		return key
+	}
+	return key[:8] + "..."
+}
@@ -0,0 +1,30 @@
+package twitch
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateStreamKey(t *testing.T) {
+	tests := map[string]bool{
+		"live_123456789_AbCdEfGhIjKlMnOpQrStUvWxYz0123": true,
+		"live_1_abcdefghijklmnopqrst":                   true,
+		"":                                              false,
+		"your_stream_key":                               false,
+		"<stream key>":                                  false,
+		"live_123456789":                                false,
+		"live_123456789_AbCdEf":                         false,
+		"live_abc_AbCdEfGhIjKlMnOpQrStUvWxYz":           false,
+		" live_123456789_AbCdEfGhIjKlMnOpQrStUvWxYz":  false,
+		"live_123456789_AbCdEfGhIjKlMnOpQrStUvWxYz\n": false,
+	}
+	for key, valid := range tests {
+		err := ValidateStreamKey(key)
+		if valid && err != nil {
+			t.Errorf("%q: %s", key, err)
+		}
+		if !valid && !errors.Is(err, ErrStreamKey) {
+			t.Errorf("%q got %v, want %v", key, err, ErrStreamKey)
+		}
+	}
+}